@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/arnodel/gobot2flags/lang"
+)
+
+func codeCmd(args []string) int {
+	flags := flag.NewFlagSet("code", flag.ExitOnError)
+	output := flags.String("o", "", "output file (default standard output)")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: g2f-export code [flags] board\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	board, err := readBoard(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	prog, err := lang.Decompile(board)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", flags.Arg(0), err)
+		return 1
+	}
+	out, err := createOutput(*output)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	_, err = io.WriteString(out, prog.String())
+	return closeOutput(out, err)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/arnodel/gobot2flags/export"
+)
+
+func dotCmd(args []string) int {
+	flags := flag.NewFlagSet("dot", flag.ExitOnError)
+	output := flags.String("o", "", "output file (default standard output)")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: g2f-export dot [flags] board\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	board, err := readBoard(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	out, err := createOutput(*output)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return closeOutput(out, export.WriteBoardDOT(out, board))
+}
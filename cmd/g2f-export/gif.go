@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/arnodel/gobot2flags/export"
+	"github.com/arnodel/gobot2flags/model"
+)
+
+func gifCmd(args []string) int {
+	flags := flag.NewFlagSet("gif", flag.ExitOnError)
+	output := flags.String("o", "", "output file (default standard output)")
+	var opts export.GIFOptions
+	flags.IntVar(&opts.MaxSteps, "maxsteps", 1000, "maximum number of commands to execute")
+	flags.IntVar(&opts.FramesPerStep, "frames", 4, "number of frames for each command")
+	flags.IntVar(&opts.Delay, "delay", 8, "delay between frames in 100ths of a second")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: g2f-export gif [flags] level board\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+	level, err := model.ReadLevelFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	board, err := readBoard(flags.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	out, err := createOutput(*output)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	result, err := export.WriteRunGIF(out, level, board, opts)
+	if status := closeOutput(out, err); status != 0 {
+		return status
+	}
+	fmt.Fprintf(os.Stderr, "%s after %d steps\n", result.Outcome, result.Steps)
+	return 0
+}
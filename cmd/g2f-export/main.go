@@ -0,0 +1,106 @@
+// Command g2f-export makes pictures of levels and runs without a display,
+// e.g. for level thumbnails, documentation or grading reports.
+//
+// Usage:
+//
+//	g2f-export png [-o output] level [board]
+//	g2f-export gif [-o output] [-maxsteps n] [-frames n] [-delay n] level board
+//	g2f-export svg [-o output] [-maxsteps n] board [level]
+//	g2f-export dot [-o output] board
+//	g2f-export code [-o output] board
+//
+// png draws the maze of the level, or its state at the end of a run of the
+// board if one is given.  gif makes an animation of a run of the board.  svg
+// draws the circuit board, highlighting the chips and arrows used by a run on
+// the level if one is given.  dot writes the control flow of the circuit board
+// as a Graphviz graph and code writes it as a program (see package lang).
+//
+// Board files contain a circuit board in the format understood by
+// model.CircuitBoardFromString.
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) int
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"png", "draw a maze as a PNG image", pngCmd},
+		{"gif", "make an animated GIF of a run", gifCmd},
+		{"svg", "draw a circuit board as an SVG image", svgCmd},
+		{"dot", "write the control flow of a circuit board as a Graphviz graph", dotCmd},
+		{"code", "write the control flow of a circuit board as a program", codeCmd},
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	// Exporting a run does not need the level controller's log of it.
+	log.SetOutput(ioutil.Discard)
+
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			os.Exit(cmd.run(os.Args[2:]))
+		}
+	}
+	usage()
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: g2f-export <command> [arguments]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "\t%-8s %s\n", cmd.name, cmd.usage)
+	}
+	os.Exit(2)
+}
+
+func readBoard(filename string) (*model.CircuitBoard, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	board, err := model.CircuitBoardFromString(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	return board, nil
+}
+
+// createOutput opens the output file, or standard output if filename is
+// empty.
+func createOutput(filename string) (*os.File, error) {
+	if filename == "" {
+		return os.Stdout, nil
+	}
+	return os.Create(filename)
+}
+
+// closeOutput closes the output file and reports the first error.
+func closeOutput(f *os.File, err error) int {
+	if f != os.Stdout {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/arnodel/gobot2flags/export"
+	"github.com/arnodel/gobot2flags/model"
+)
+
+func pngCmd(args []string) int {
+	flags := flag.NewFlagSet("png", flag.ExitOnError)
+	output := flags.String("o", "", "output file (default standard output)")
+	maxSteps := flags.Int("maxsteps", 1000, "maximum number of commands to execute when a board is given")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: g2f-export png [flags] level [board]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		flags.Usage()
+		return 2
+	}
+	level, err := model.ReadLevelFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	maze := level.Maze
+	if flags.NArg() == 2 {
+		board, err := readBoard(flags.Arg(1))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		maze = model.RunLevel(level, board, *maxSteps).Maze
+	}
+	out, err := createOutput(*output)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return closeOutput(out, export.WriteMazePNG(out, maze))
+}
@@ -0,0 +1,187 @@
+// Command g2f-run runs circuit boards on a level without a display and
+// reports how they did.  It is meant for grading solutions in bulk.
+//
+// Usage:
+//
+//	g2f-run [-maxsteps n] [-q] [-trace] [-record] level board...
+//	g2f-run -verify level replay...
+//
+// Each board file contains a circuit board in the format understood by
+// model.CircuitBoardFromString, or a program (see package lang) if its name
+// ends in .bot, which is compiled onto a board of the size of the level.  With -record, a replay of each run is written
+// next to the board file, in board.replay.json.  With -verify, replays are
+// run again to check that they are genuine.  The exit status is 0 if all
+// boards (or replays) win the level, 1 if some board fails (or some replay
+// does not reproduce) and 2 if a file cannot be read.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/arnodel/gobot2flags/lang"
+	"github.com/arnodel/gobot2flags/model"
+)
+
+// Extension of program files
+const programExt = ".bot"
+
+const (
+	exitWon    = 0
+	exitFailed = 1
+	exitError  = 2
+)
+
+func main() {
+	maxSteps := flag.Int("maxsteps", 1000, "maximum number of commands to execute")
+	quiet := flag.Bool("q", false, "only print the outcome of each run")
+	trace := flag.Bool("trace", false, "print the maze and the circuit board after each step")
+	record := flag.Bool("record", false, "write a replay of each run to board.replay.json")
+	verify := flag.Bool("verify", false, "check replays instead of running boards")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: g2f-run [flags] level board...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 2 {
+		flag.Usage()
+		os.Exit(exitError)
+	}
+
+	// Use -trace to see the steps, the level controller's log of them is
+	// too verbose.
+	log.SetOutput(ioutil.Discard)
+
+	level, err := model.ReadLevelFile(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitError)
+	}
+
+	if *verify {
+		os.Exit(verifyReplays(level, flag.Args()[1:]))
+	}
+
+	status := exitWon
+	boardFiles := flag.Args()[1:]
+	for _, boardFile := range boardFiles {
+		board, err := readBoard(boardFile, level)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = exitError
+			continue
+		}
+		replay := model.NewReplay(level, board)
+		var result model.RunResult
+		if *trace || *record {
+			result = model.RunLevelFunc(level, board, *maxSteps, func(c *model.LevelController) {
+				if *trace {
+					fmt.Println(c.Render(true))
+				}
+				if *record {
+					replay.Record(c)
+				}
+			})
+		} else {
+			// Much faster when grading many boards
+			result = model.CompileBoard(board).Run(level, *maxSteps)
+		}
+		if *record {
+			if err := writeReplay(boardFile+".replay.json", replay); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				status = exitError
+			}
+		}
+		if len(boardFiles) > 1 {
+			fmt.Printf("%s: ", boardFile)
+		}
+		if *quiet {
+			fmt.Println(result.Outcome)
+		} else {
+			printResult(os.Stdout, level, result)
+		}
+		if result.Outcome != model.Won && !*quiet {
+			// The problems with the board may explain why
+			for _, w := range model.AnalyzeBoard(board) {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", boardFile, w)
+			}
+		}
+		if result.Outcome != model.Won && status == exitWon {
+			status = exitFailed
+		}
+	}
+	os.Exit(status)
+}
+
+// verifyReplays checks that each replay wins the level and that running its
+// board again gives the same result.
+func verifyReplays(level *model.Level, replayFiles []string) int {
+	status := exitWon
+	for _, replayFile := range replayFiles {
+		data, err := ioutil.ReadFile(replayFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = exitError
+			continue
+		}
+		replay, err := model.ReplayFromJSON(data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", replayFile, err)
+			status = exitError
+			continue
+		}
+		if err := replay.Verify(level); err != nil {
+			fmt.Printf("%s: invalid replay: %s\n", replayFile, err)
+			if status == exitWon {
+				status = exitFailed
+			}
+			continue
+		}
+		fmt.Printf("%s: %s, %d steps, cost $%d\n", replayFile, replay.Final.Outcome, len(replay.Steps), replay.Final.Cost)
+		if replay.Final.Outcome != model.Won.String() && status == exitWon {
+			status = exitFailed
+		}
+	}
+	return status
+}
+
+func writeReplay(filename string, replay *model.Replay) error {
+	data, err := model.ReplayToJSON(replay)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+func printResult(w io.Writer, level *model.Level, result model.RunResult) {
+	fmt.Fprintf(w, "%s\n", result.Outcome)
+	fmt.Fprintf(w, "steps: %d\n", result.Steps)
+	fmt.Fprintf(w, "flags: %d/%d\n", result.FlagsCaptured, result.FlagsCaptured+result.Maze.FlagsRemaining())
+	fmt.Fprintf(w, "chip cost: $%d (%d chips)\n", result.ChipCost(level), result.Chips)
+	fmt.Fprintf(w, "move cost: $%d\n", result.MoveCost(level))
+	fmt.Fprintf(w, "cost: $%d\n", result.Cost)
+	fmt.Fprint(w, result.Maze.Render())
+}
+
+func readBoard(filename string, level *model.Level) (*model.CircuitBoard, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var board *model.CircuitBoard
+	if filepath.Ext(filename) == programExt {
+		w, h := level.BoardSize()
+		board, err = lang.CompileString(string(data), w, h)
+	} else {
+		board, err = model.CircuitBoardFromString(string(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	return board, nil
+}
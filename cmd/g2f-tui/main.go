@@ -0,0 +1,106 @@
+// Command g2f-tui plays the game in a terminal, e.g. over SSH.  It does not
+// depend on ebiten and runs the circuit board with the same model as the
+// game.
+//
+// Usage:
+//
+//	g2f-tui level [board]
+//
+// The level file can be in any format known to the model package.  If a
+// board file is given, the circuit board is read from it if it exists, and
+// saved to it with ctrl-s.  The terminal is put in raw mode with stty, so it
+// only runs on Unix systems.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"time"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: g2f-tui level [board]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 || flag.NArg() > 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// The level controller logs every step, which would mess up the screen.
+	log.SetOutput(ioutil.Discard)
+
+	level, err := model.ReadLevelFile(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	board := model.NewCircuitBoard(level.BoardWidth, level.BoardHeigth)
+	boardFile := flag.Arg(1)
+	if boardFile != "" {
+		board, err = readBoard(boardFile, board)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	term, err := openTerminal()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot set up the terminal: %s\n", err)
+		os.Exit(1)
+	}
+	defer term.close()
+	run(term, newUI(level, board, boardFile))
+}
+
+// run redraws the screen after each key and each step of the run until the
+// player quits.
+func run(term *terminal, u *ui) {
+	keys := make(chan key)
+	go readKeys(keys)
+	timer := time.NewTimer(u.delay())
+	for !u.quit {
+		term.draw(u.lines())
+		select {
+		case k, ok := <-keys:
+			if !ok {
+				return
+			}
+			u.handleKey(k)
+		case <-timer.C:
+			if u.playing {
+				u.step()
+			}
+			timer.Reset(u.delay())
+		}
+	}
+}
+
+// readBoard reads the board in filename, or returns empty if the file does
+// not exist yet.
+func readBoard(filename string, empty *model.CircuitBoard) (*model.CircuitBoard, error) {
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return empty, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	board, err := model.CircuitBoardFromString(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	w, h := board.Size()
+	if ew, eh := empty.Size(); w != ew || h != eh {
+		return nil, fmt.Errorf("%s: the board is %dx%d but the level needs %dx%d", filename, w, h, ew, eh)
+	}
+	return board, nil
+}
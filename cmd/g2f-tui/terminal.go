@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+// draw replaces what is on the screen with lines.
+func (t *terminal) draw(lines []string) {
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	b.WriteString(strings.Join(lines, "\r\n"))
+	os.Stdout.WriteString(b.String())
+}
+
+type keyKind int
+
+const (
+	runeKey keyKind = iota
+	arrowKey
+	escapeKey
+)
+
+type key struct {
+	kind  keyKind
+	r     rune
+	dir   model.Orientation // For arrow keys
+	shift bool              // For arrow keys
+}
+
+// readKeys sends the keys typed on the terminal to keys.  Escape sequences
+// for arrow keys arrive in one read, so each read is parsed on its own.
+func readKeys(keys chan<- key) {
+	buf := make([]byte, 64)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			close(keys)
+			return
+		}
+		for _, k := range parseKeys(buf[:n]) {
+			keys <- k
+		}
+	}
+}
+
+var arrowDirs = map[byte]model.Orientation{
+	'A': model.North,
+	'B': model.South,
+	'C': model.East,
+	'D': model.West,
+}
+
+func parseKeys(b []byte) []key {
+	var keys []key
+	for len(b) > 0 {
+		if b[0] != 0x1b {
+			keys = append(keys, key{kind: runeKey, r: rune(b[0])})
+			b = b[1:]
+			continue
+		}
+		if len(b) == 1 || b[1] != '[' && b[1] != 'O' {
+			keys = append(keys, key{kind: escapeKey})
+			b = b[1:]
+			continue
+		}
+		// Find the end of the sequence
+		i := 2
+		for i < len(b) && (b[i] < 0x40 || b[i] > 0x7e) {
+			i++
+		}
+		if i == len(b) {
+			break
+		}
+		if dir, ok := arrowDirs[b[i]]; ok {
+			// "\x1b[1;2A" is shift + up
+			shift := strings.HasSuffix(string(b[2:i]), ";2")
+			keys = append(keys, key{kind: arrowKey, dir: dir, shift: shift})
+		}
+		b = b[i+1:]
+	}
+	return keys
+}
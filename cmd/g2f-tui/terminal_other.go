@@ -0,0 +1,18 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !illumos && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!illumos,!linux,!netbsd,!openbsd,!solaris
+
+package main
+
+import (
+	"errors"
+	"runtime"
+)
+
+// Only Unix terminals can be put in raw mode, see terminal_stty.go.
+type terminal struct{}
+
+func openTerminal() (*terminal, error) {
+	return nil, errors.New("only Unix terminals are supported, not " + runtime.GOOS)
+}
+
+func (t *terminal) close() {}
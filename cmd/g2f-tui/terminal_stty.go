@@ -0,0 +1,41 @@
+//go:build aix || darwin || dragonfly || freebsd || illumos || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd illumos linux netbsd openbsd solaris
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// The terminal is put in raw mode with stty so that there is no need for a
+// terminal library.
+type terminal struct {
+	savedMode string
+}
+
+func openTerminal() (*terminal, error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, err
+	}
+	// Switch to the alternate screen and hide the cursor
+	os.Stdout.WriteString("\x1b[?1049h\x1b[?25l")
+	return &terminal{savedMode: strings.TrimSpace(saved)}, nil
+}
+
+func (t *terminal) close() {
+	os.Stdout.WriteString("\x1b[?25h\x1b[?1049l")
+	stty(t.savedMode)
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
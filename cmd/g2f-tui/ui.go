@@ -0,0 +1,336 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+// Delays between two steps of a run, from slowest to fastest.
+var speeds = []time.Duration{
+	time.Second,
+	500 * time.Millisecond,
+	250 * time.Millisecond,
+	100 * time.Millisecond,
+	30 * time.Millisecond,
+}
+
+var chipKeys = map[rune]model.ChipType{
+	's': model.StartChip,
+	'f': model.ForwardChip,
+	'l': model.TurnLeftChip,
+	'r': model.TurnRightChip,
+	'w': model.IsWallAheadChip,
+	'1': model.IsFloorRedChip,
+	'2': model.IsFloorYellowChip,
+	'3': model.IsFloorBlueChip,
+	'4': model.PaintRedChip,
+	'5': model.PaintYellowChip,
+	'6': model.PaintBlueChip,
+	'x': model.NoChip,
+}
+
+var helpLines = []string{
+	"Arrows: move   s: start   f: forward   l/r: turn left/right   w: wall ahead?",
+	"1/2/3: floor red/yellow/blue?   4/5/6: paint red/yellow/blue   x: remove chip",
+	"y/n + arrow (or shift + arrow): draw yes/no arrow   e + arrow: erase arrow",
+	"p: play/pause   space: step   z: rewind   +/-: speed   c: clear board",
+	"ctrl-s: save board   C: colours on/off   q: quit",
+}
+
+// A ui is the state of the game in the terminal.
+type ui struct {
+	level     *model.Level
+	board     *model.CircuitBoard
+	boardFile string
+	cursor    model.Position
+
+	// arrowType is the kind of arrow the next arrow key draws, or NoArrow if
+	// it moves the cursor.  erasing is true if it erases an arrow instead.
+	arrowType model.ArrowType
+	erasing   bool
+
+	controller *model.LevelController // Not nil while a run is in progress
+	playing    bool
+	speed      int
+	color      bool
+	message    string
+	quit       bool
+}
+
+func newUI(level *model.Level, board *model.CircuitBoard, boardFile string) *ui {
+	u := &ui{
+		level:     level,
+		board:     board,
+		boardFile: boardFile,
+		speed:     1,
+		color:     true,
+	}
+	if pos, ok := board.StartPos(); ok {
+		u.cursor = pos
+	} else {
+		u.cursor = model.Position{X: level.BoardWidth / 2, Y: level.BoardHeigth / 2}
+	}
+	return u
+}
+
+func (u *ui) delay() time.Duration {
+	return speeds[u.speed]
+}
+
+func (u *ui) handleKey(k key) {
+	u.message = ""
+	switch k.kind {
+	case escapeKey:
+		u.arrowType = model.NoArrow
+		u.erasing = false
+	case arrowKey:
+		switch {
+		case k.shift:
+			u.drawArrow(k.dir, model.ArrowYes)
+		case u.erasing:
+			u.eraseArrow(k.dir)
+		case u.arrowType != model.NoArrow:
+			u.drawArrow(k.dir, u.arrowType)
+		default:
+			u.moveCursor(k.dir)
+		}
+		u.arrowType = model.NoArrow
+		u.erasing = false
+	case runeKey:
+		u.handleRune(k.r)
+	}
+}
+
+func (u *ui) handleRune(r rune) {
+	if t, ok := chipKeys[r]; ok {
+		if u.canEdit() {
+			u.board.SetChipAt(u.cursor.X, u.cursor.Y, u.board.ChipAt(u.cursor.X, u.cursor.Y).WithType(t))
+		}
+		return
+	}
+	switch r {
+	case 'y':
+		u.arrowType = model.ArrowYes
+	case 'n':
+		u.arrowType = model.ArrowNo
+	case 'e':
+		u.erasing = true
+	case 'c':
+		if u.canEdit() {
+			u.board.Reset()
+		}
+	case 'p':
+		if u.playing {
+			u.playing = false
+		} else if u.start() {
+			u.playing = true
+		}
+	case ' ':
+		u.playing = false
+		if u.start() {
+			u.step()
+		}
+	case 'z':
+		u.rewind()
+	case '+', '=':
+		if u.speed < len(speeds)-1 {
+			u.speed++
+		}
+	case '-':
+		if u.speed > 0 {
+			u.speed--
+		}
+	case 'C':
+		u.color = !u.color
+	case 0x13: // ctrl-s
+		u.save()
+	case 'q', 0x03: // ctrl-c
+		u.quit = true
+	}
+}
+
+// canEdit returns true if the board can be changed, i.e. there is no run in
+// progress.
+func (u *ui) canEdit() bool {
+	if u.controller != nil {
+		u.message = "Rewind (z) to change the board"
+		return false
+	}
+	return true
+}
+
+func (u *ui) moveCursor(o model.Orientation) {
+	next := u.cursor.Move(o.VelocityForward())
+	if u.board.Contains(next.X, next.Y) {
+		u.cursor = next
+	}
+}
+
+// drawArrow draws an arrow from the slot under the cursor to the next one in
+// direction o and moves the cursor there, so paths can be drawn one arrow
+// after the other.
+func (u *ui) drawArrow(o model.Orientation, t model.ArrowType) {
+	next := u.cursor.Move(o.VelocityForward())
+	if !u.canEdit() || !u.board.Contains(next.X, next.Y) {
+		return
+	}
+	chip := u.board.ChipAt(u.cursor.X, u.cursor.Y)
+	if t == model.ArrowNo && !chip.IsTest() {
+		u.message = "Only decision chips have no arrows"
+		return
+	}
+	u.board.SetChipAt(u.cursor.X, u.cursor.Y, chip.WithArrow(o, t))
+	u.cursor = next
+}
+
+// eraseArrow removes the arrows between the slot under the cursor and the
+// next one in direction o.
+func (u *ui) eraseArrow(o model.Orientation) {
+	next := u.cursor.Move(o.VelocityForward())
+	if !u.canEdit() || !u.board.Contains(next.X, next.Y) {
+		return
+	}
+	u.board.SetChipAt(u.cursor.X, u.cursor.Y, u.board.ChipAt(u.cursor.X, u.cursor.Y).ClearArrow(o))
+	u.board.SetChipAt(next.X, next.Y, u.board.ChipAt(next.X, next.Y).ClearArrow(o.Reverse()))
+}
+
+// start starts a run if there is none in progress and returns true if there
+// is one.
+func (u *ui) start() bool {
+	if u.controller != nil {
+		return true
+	}
+	u.controller = model.NewLevelController(u.level, u.board)
+	if u.controller == nil {
+		u.message = "Place a start chip (s) first"
+		return false
+	}
+	return true
+}
+
+func (u *ui) step() {
+	if u.controller == nil || u.controller.GameWon() || u.controller.DeadEnd() {
+		u.playing = false
+		return
+	}
+	u.controller.Advance()
+}
+
+func (u *ui) rewind() {
+	if u.controller != nil {
+		u.board.ClearActiveChips()
+		u.controller = nil
+	}
+	u.playing = false
+}
+
+func (u *ui) save() {
+	if u.boardFile == "" {
+		u.message = "No board file was given on the command line"
+		return
+	}
+	board := u.board.Clone()
+	board.ClearActiveChips()
+	if err := ioutil.WriteFile(u.boardFile, []byte(board.String()), 0644); err != nil {
+		u.message = err.Error()
+		return
+	}
+	u.message = "Saved " + u.boardFile
+}
+
+// lines returns what should be on the screen: the maze and the board side
+// by side, then the state of the run and the help.
+func (u *ui) lines() []string {
+	maze := u.level.Maze
+	if u.controller != nil {
+		maze = u.controller.Maze()
+	}
+	mazeLines := strings.Split(strings.TrimSuffix(maze.Render(), "\n"), "\n")
+	boardLines := strings.Split(strings.TrimSuffix(u.board.Render(), "\n"), "\n")
+	width := len(mazeLines[0])
+
+	lines := []string{u.level.Name, ""}
+	for i := 0; i < len(mazeLines) || i < len(boardLines); i++ {
+		left := strings.Repeat(" ", width)
+		if i < len(mazeLines) {
+			left = u.colorMazeLine(i, mazeLines[i])
+		}
+		var right string
+		if i < len(boardLines) {
+			right = u.boardLine(i, boardLines[i])
+		}
+		lines = append(lines, left+"    "+right)
+	}
+	lines = append(lines, "", u.status(), u.message, "")
+	return append(lines, helpLines...)
+}
+
+var floorColors = map[byte]string{
+	'R': "\x1b[41m",
+	'Y': "\x1b[43m",
+	'B': "\x1b[44m",
+}
+
+// colorMazeLine paints the floor of the cells in the ith line of the maze.
+func (u *ui) colorMazeLine(i int, line string) string {
+	if !u.color || i%2 == 0 {
+		return line
+	}
+	var b strings.Builder
+	for j := 0; j < len(line); j++ {
+		if j%3 == 1 && j+1 < len(line) {
+			if esc, ok := floorColors[line[j]]; ok {
+				fmt.Fprintf(&b, "%s%s\x1b[0m", esc, line[j:j+2])
+				j++
+				continue
+			}
+		}
+		b.WriteByte(line[j])
+	}
+	return b.String()
+}
+
+// boardLine shows the cursor in reverse video on the ith line of the board.
+func (u *ui) boardLine(i int, line string) string {
+	if i != 2*u.cursor.Y {
+		return line
+	}
+	j := 1 + 6*u.cursor.X
+	return line[:j] + "\x1b[7m" + line[j:j+2] + "\x1b[0m" + line[j+2:]
+}
+
+func (u *ui) status() string {
+	var state string
+	c := u.controller
+	switch {
+	case c == nil:
+		state = "editing"
+	case c.GameWon():
+		state = "won!"
+	case c.DeadEnd():
+		state = "dead end"
+	case u.playing:
+		state = "playing"
+	default:
+		state = "paused"
+	}
+	var mode string
+	switch {
+	case u.erasing:
+		mode = " - erase arrow: press an arrow key"
+	case u.arrowType == model.ArrowYes:
+		mode = " - yes arrow: press an arrow key"
+	case u.arrowType == model.ArrowNo:
+		mode = " - no arrow: press an arrow key"
+	}
+	if c == nil {
+		return fmt.Sprintf("%s, %d chips, speed %d%s", state, u.board.ChipCount(), u.speed+1, mode)
+	}
+	m := c.Maze()
+	return fmt.Sprintf("%s, flags %d/%d, cost $%d, speed %d%s",
+		state, m.FlagsCaptured(), m.FlagsCaptured()+m.FlagsRemaining(), c.Score(), u.speed+1, mode)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+func convertCmd(args []string) int {
+	flags := flag.NewFlagSet("convert", flag.ExitOnError)
+	to := flags.String("to", "", "output format (r2f or json), defaults to the format given by the output file extension")
+	output := flags.String("o", "", "output file (default standard output)")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: r2f convert [-to format] [-o output] file\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	var format model.LevelFormat
+	var ok bool
+	switch {
+	case *to != "":
+		format, ok = model.LevelFormatByName(*to)
+	case *output != "":
+		format, ok = model.LevelFormatForFile(*output)
+	default:
+		fmt.Fprintln(os.Stderr, "r2f convert: one of -to or -o must be given")
+		return 2
+	}
+	if !ok {
+		fmt.Fprintln(os.Stderr, "r2f convert: unknown output format")
+		return 2
+	}
+
+	filename := flags.Arg(0)
+	level, err := readLevel(filename)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	name := model.LevelNameForFile(filename)
+	if *output != "" {
+		name = model.LevelNameForFile(*output)
+	}
+	data, err := format.Encode(level, name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", filename, err)
+		return 1
+	}
+	if *output == "" {
+		os.Stdout.Write(data)
+		return 0
+	}
+	if err := ioutil.WriteFile(*output, data, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
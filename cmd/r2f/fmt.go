@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+func fmtCmd(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	list := flags.Bool("l", false, "list files whose formatting differs from canonical form and fail if there are any")
+	write := flags.Bool("w", false, "write result to the source file instead of standard output")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: r2f fmt [-l] [-w] [files...]\n\nWithout files, formats a .r2f level read from standard input.\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		data, err := ioutil.ReadAll(os.Stdin)
+		if err == nil {
+			data, err = formatLevel("<stdin>", model.R2FLevelFormat, data)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		os.Stdout.Write(data)
+		return 0
+	}
+
+	status := 0
+	for _, filename := range flags.Args() {
+		changed, err := fmtFile(filename, *list, *write)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+		} else if changed && *list {
+			status = 1
+		}
+	}
+	return status
+}
+
+func fmtFile(filename string, list, write bool) (bool, error) {
+	format, ok := model.LevelFormatForFile(filename)
+	if !ok {
+		return false, fmt.Errorf("%s: unknown level format", filename)
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return false, err
+	}
+	formatted, err := formatLevel(filename, format, data)
+	if err != nil {
+		return false, err
+	}
+	changed := !bytes.Equal(data, formatted)
+	if list && changed {
+		fmt.Println(filename)
+	}
+	if write {
+		if changed {
+			return true, ioutil.WriteFile(filename, formatted, 0644)
+		}
+	} else if !list {
+		os.Stdout.Write(formatted)
+	}
+	return changed, nil
+}
+
+func formatLevel(filename string, format model.LevelFormat, data []byte) ([]byte, error) {
+	formatted, err := model.FormatLevel(format, model.LevelNameForFile(filename), data)
+	if err != nil {
+		return nil, fileError(filename, err)
+	}
+	return formatted, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/arnodel/gobot2flags/generator"
+	"github.com/arnodel/gobot2flags/model"
+)
+
+func genCmd(args []string) int {
+	flags := flag.NewFlagSet("gen", flag.ExitOnError)
+	var opts generator.Options
+	flags.Int64Var(&opts.Seed, "seed", 0, "seed for the random generator (default based on the time)")
+	flags.StringVar(&opts.Name, "name", "", "name of the level (default the name of the output file)")
+	flags.IntVar(&opts.Width, "width", 8, "width of the maze")
+	flags.IntVar(&opts.Height, "height", 6, "height of the maze")
+	flags.IntVar(&opts.Flags, "flags", 2, "number of flags")
+	flags.Float64Var(&opts.WallDensity, "walls", 1, "fraction of the walls of the maze to keep, between 0 and 1")
+	flags.BoolVar(&opts.Toroidal, "toroidal", false, "let corridors go across the edges of the maze")
+	algo := flags.String("algo", generator.RecursiveBacktracker.String(), "maze algorithm: backtracker, prim or rooms")
+	colors := flags.String("colors", generator.SingleColor.String(), "floor colors: single, random or patches")
+	output := flags.String("o", "", "output file, .r2f or .json (default .r2f on standard output)")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: r2f gen [flags]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return 2
+	}
+
+	var ok bool
+	if opts.Algorithm, ok = generator.AlgorithmByName(*algo); !ok {
+		fmt.Fprintf(os.Stderr, "r2f gen: unknown algorithm %q\n", *algo)
+		return 2
+	}
+	if opts.Colors, ok = generator.ColorLayoutByName(*colors); !ok {
+		fmt.Fprintf(os.Stderr, "r2f gen: unknown color layout %q\n", *colors)
+		return 2
+	}
+	format := model.R2FLevelFormat
+	if *output != "" {
+		if format, ok = model.LevelFormatForFile(*output); !ok {
+			fmt.Fprintln(os.Stderr, "r2f gen: unknown output format")
+			return 2
+		}
+		if opts.Name == "" {
+			opts.Name = model.LevelNameForFile(*output)
+		}
+	}
+	if opts.Seed == 0 {
+		opts.Seed = time.Now().UnixNano()
+		fmt.Fprintf(os.Stderr, "seed: %d\n", opts.Seed)
+	}
+
+	level, err := generator.Generate(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "r2f gen: %s\n", err)
+		return 1
+	}
+	data, err := format.Encode(level, model.LevelNameForFile(*output))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "r2f gen: %s\n", err)
+		return 1
+	}
+	if *output == "" {
+		os.Stdout.Write(data)
+		return 0
+	}
+	if err := ioutil.WriteFile(*output, data, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
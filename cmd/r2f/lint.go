@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+func lintCmd(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	strict := flags.Bool("strict", false, "fail if there are warnings")
+	verbose := flags.Bool("v", false, "print what the robot can reach in each level")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: r2f lint [-strict] [-v] files...\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	status := 0
+	for _, filename := range flags.Args() {
+		level, warnings, err := lintFile(filename)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+		for _, w := range warnings {
+			fmt.Fprintln(os.Stderr, diagnostic(filename, w, "warning: "))
+		}
+		if *strict && len(warnings) > 0 {
+			status = 1
+		}
+		if *verbose && level.Maze.Robot() != nil {
+			printAnalysis(filename, model.AnalyzeMaze(level.Maze))
+		}
+	}
+	return status
+}
+
+func lintFile(filename string) (*model.Level, []*model.ParseError, error) {
+	format, ok := model.LevelFormatForFile(filename)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s: unknown level format", filename)
+	}
+	if format.Name != model.R2FLevelFormat.Name {
+		level, err := readLevel(filename)
+		if err != nil {
+			return nil, nil, err
+		}
+		return level, model.LintLevel(level), nil
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	level, warnings, err := model.LintLevelString(model.LevelNameForFile(filename), string(data))
+	if err != nil {
+		return nil, nil, fileError(filename, err)
+	}
+	return level, warnings, nil
+}
+
+func printAnalysis(filename string, a *model.MazeAnalysis) {
+	flags := len(a.ReachableFlags) + len(a.SealedFlags)
+	minMoves := "unknown"
+	if a.MinMoves >= 0 {
+		minMoves = fmt.Sprint(a.MinMoves)
+	}
+	fmt.Printf("%s: %d cells reachable, %d/%d flags reachable, minimum moves to win: %s\n",
+		filename, len(a.Reachable), len(a.ReachableFlags), flags, minMoves)
+}
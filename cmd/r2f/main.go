@@ -0,0 +1,98 @@
+// Command r2f checks, formats and converts level files.  It does not depend
+// on ebiten so it can be used anywhere, e.g. in pre-commit hooks.
+//
+// Usage:
+//
+//	r2f lint [-strict] [-v] files...
+//	r2f fmt [-l] [-w] [files...]
+//	r2f convert [-to format] [-o output] file
+//	r2f gen [-seed n] [-width n] [-height n] [-flags n] [-walls d] [-toroidal]
+//	        [-algo algorithm] [-colors layout] [-o output]
+//	r2f solve [-maxchips n] [-maxsteps n] [-maxnodes n] [-timeout d] files...
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) int
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"lint", "check levels for errors and likely mistakes", lintCmd},
+		{"fmt", "rewrite levels in canonical form", fmtCmd},
+		{"convert", "convert a level to another format", convertCmd},
+		{"gen", "generate a random level", genCmd},
+		{"solve", "find the cheapest circuit board for levels", solveCmd},
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			os.Exit(cmd.run(os.Args[2:]))
+		}
+	}
+	usage()
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: r2f <command> [arguments]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "\t%-8s %s\n", cmd.name, cmd.usage)
+	}
+	os.Exit(2)
+}
+
+// readLevel reads a level file, reporting errors in the file like
+// fileError.
+func readLevel(filename string) (*model.Level, error) {
+	level, err := model.ReadLevelFile(filename)
+	var ferr *model.LevelFileError
+	if errors.As(err, &ferr) {
+		return nil, fileError(ferr.Filename, ferr.Err)
+	}
+	return level, err
+}
+
+// fileError prefixes each error in err with the filename and the position of
+// the error, as compilers do.
+func fileError(filename string, err error) error {
+	switch perr := err.(type) {
+	case model.ParseErrors:
+		msgs := make([]string, len(perr))
+		for i, e := range perr {
+			msgs[i] = diagnostic(filename, e, "")
+		}
+		return fmt.Errorf("%s", strings.Join(msgs, "\n"))
+	case *model.ParseError:
+		return fmt.Errorf("%s", diagnostic(filename, perr, ""))
+	default:
+		return fmt.Errorf("%s: %s", filename, err)
+	}
+}
+
+func diagnostic(filename string, e *model.ParseError, prefix string) string {
+	pos := filename
+	if e.Line != 0 {
+		pos += fmt.Sprintf(":%d", e.Line)
+		if e.Col != 0 {
+			pos += fmt.Sprintf(":%d", e.Col)
+		}
+	}
+	return fmt.Sprintf("%s: %s%s", pos, prefix, e.Msg)
+}
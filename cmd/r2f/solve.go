@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/arnodel/gobot2flags/solver"
+)
+
+func solveCmd(args []string) int {
+	flags := flag.NewFlagSet("solve", flag.ExitOnError)
+	var opts solver.Options
+	flags.IntVar(&opts.MaxChips, "maxchips", 6, "largest number of chips to try")
+	flags.IntVar(&opts.MaxSteps, "maxsteps", 1000, "maximum number of commands in a run")
+	flags.IntVar(&opts.MaxNodes, "maxnodes", 0, "give up after exploring that many boards (0 for no limit)")
+	flags.DurationVar(&opts.Timeout, "timeout", 0, "give up after that long (0 for no limit)")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: r2f solve [flags] files...\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	// The solver runs the boards it finds, and the maze logs every flag
+	// they capture.
+	log.SetOutput(ioutil.Discard)
+
+	status := 0
+	for _, filename := range flags.Args() {
+		level, err := readLevel(filename)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+		res, err := solver.Solve(level, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", filename, err)
+			status = 1
+			continue
+		}
+		search := "complete"
+		if !res.Complete {
+			search = "interrupted"
+		}
+		if res.Best == nil {
+			fmt.Printf("%s: no solution found (search %s after %d boards)\n", filename, search, res.Nodes)
+			status = 1
+			continue
+		}
+		best := res.Best
+		fmt.Printf("%s: cost $%d, %d chips, %d steps (search %s after %d boards)\n",
+			filename, best.Cost, best.Chips, best.Steps, search, res.Nodes)
+		fmt.Print(best.Board)
+	}
+	return status
+}
@@ -0,0 +1,146 @@
+package engine
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// Space around and between the parts of a dialog, and size of the buttons.
+const (
+	dialogPadding      = 20
+	dialogButtonMargin = 10
+	dialogButtonHeight = 30
+)
+
+// Dialogs fade in and out quickly.
+var dialogTransition = Transition{Kind: Fade, Frames: 10}
+
+// A Dialog is a modal overlay showing a message and a row of buttons.  Show
+// it with ShowDialog; it closes itself when a button is chosen.  Enter or
+// space chooses the button highlighted with the arrow keys (the first one to
+// begin with) and Escape chooses the last one.
+type Dialog struct {
+	message     string
+	buttons     []string
+	choose      func(int)
+	selector    Selector
+	highlighted int
+	box         image.Rectangle
+	buttonRects []image.Rectangle
+}
+
+var _ View = (*Dialog)(nil)
+
+// NewDialog returns a dialog that calls choose with the index of the button
+// chosen after closing.
+func NewDialog(message string, buttons []string, choose func(int)) *Dialog {
+	return &Dialog{
+		message: message,
+		buttons: buttons,
+		choose:  choose,
+	}
+}
+
+// ShowDialog pushes a dialog over the views of the container.
+func ShowDialog(vc ViewContainer, d *Dialog) {
+	vc.PushOverlay(d, dialogTransition)
+}
+
+func (d *Dialog) Update(vc ViewContainer) error {
+	d.layout(vc.OutsideRect())
+	pointer := vc.Pointer()
+	if d.selector.Update(d.buttonAt(pointer.CurrentPos()), pointer.Status()) == Select {
+		d.close(vc, d.selector.SelectIndex)
+		return nil
+	}
+	k := vc.Keyboard()
+	n := len(d.buttons)
+	switch {
+	case n == 0:
+		if k.JustPressed(ebiten.KeyEnter) || k.JustPressed(ebiten.KeyEscape) {
+			d.close(vc, -1)
+		}
+	case k.Repeated(ebiten.KeyArrowRight), k.Repeated(ebiten.KeyTab):
+		d.highlighted = (d.highlighted + 1) % n
+	case k.Repeated(ebiten.KeyArrowLeft):
+		d.highlighted = (d.highlighted + n - 1) % n
+	case k.JustPressed(ebiten.KeyEnter), k.JustPressed(ebiten.KeySpace):
+		d.close(vc, d.highlighted)
+	case k.JustPressed(ebiten.KeyEscape):
+		d.close(vc, n-1)
+	}
+	return nil
+}
+
+func (d *Dialog) close(vc ViewContainer, i int) {
+	vc.PopView(dialogTransition)
+	if d.choose != nil {
+		d.choose(i)
+	}
+}
+
+func (d *Dialog) Draw(screen *ebiten.Image) {
+	w, h := screen.Size()
+	d.layout(image.Rect(0, 0, w, h))
+	ebitenutil.DrawRect(screen, 0, 0, float64(w), float64(h), color.RGBA{0, 0, 0, 0xa0})
+	ebitenutil.DrawRect(screen, float64(d.box.Min.X), float64(d.box.Min.Y), float64(d.box.Dx()), float64(d.box.Dy()), color.RGBA{0x30, 0x30, 0x30, 0xff})
+	drawRect(screen, d.box, color.White)
+
+	msg := TextBounds(d.message, 0, 0)
+	DrawText(screen, d.message, d.box.Min.X+(d.box.Dx()-msg.Dx())/2-msg.Min.X, d.box.Min.Y+dialogPadding-msg.Min.Y, color.White)
+	for i, label := range d.buttons {
+		var col color.Color
+		if d.selector.IsSelecting(i) {
+			col = color.RGBA{0xff, 0, 0, 0xff}
+		} else if i == d.highlighted {
+			col = color.RGBA{0xff, 0xc8, 0, 0xff}
+		} else {
+			col = color.White
+		}
+		r := d.buttonRects[i]
+		drawRect(screen, r, col)
+		tr := CenterRect(r, TextBounds(label, 0, 0))
+		DrawText(screen, label, tr.X, tr.Y, col)
+	}
+}
+
+// layout centers the dialog on the screen, with the message above the
+// buttons.
+func (d *Dialog) layout(screen image.Rectangle) {
+	buttonWidth := 0
+	for _, label := range d.buttons {
+		if bw := TextBounds(label, 0, 0).Dx() + 2*dialogButtonMargin; bw > buttonWidth {
+			buttonWidth = bw
+		}
+	}
+	n := len(d.buttons)
+	rowWidth := n*buttonWidth + (n-1)*dialogPadding
+	msg := TextBounds(d.message, 0, 0)
+	width := msg.Dx()
+	if rowWidth > width {
+		width = rowWidth
+	}
+	size := image.Rect(0, 0, width+2*dialogPadding, msg.Dy()+dialogButtonHeight+3*dialogPadding)
+	d.box = size.Add(CenterRect(screen, size))
+
+	d.buttonRects = d.buttonRects[:0]
+	x := d.box.Min.X + (d.box.Dx()-rowWidth)/2
+	y := d.box.Min.Y + msg.Dy() + 2*dialogPadding
+	for range d.buttons {
+		d.buttonRects = append(d.buttonRects, image.Rect(x, y, x+buttonWidth, y+dialogButtonHeight))
+		x += buttonWidth + dialogPadding
+	}
+}
+
+// buttonAt returns the index of the button at p, or -1.
+func (d *Dialog) buttonAt(p image.Point) int {
+	for i, r := range d.buttonRects {
+		if p.In(r) {
+			return i
+		}
+	}
+	return -1
+}
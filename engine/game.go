@@ -16,15 +16,38 @@ type ViewContainer interface {
 	OutsideSize() (int, int)
 	OutsideRect() image.Rectangle
 	Pointer() *PointerTracker
+	Keyboard() *Keyboard
+	Gestures() *GestureTracker
+
+	// Changes to the view stack are made at the start of the next update.
+	// Only the top view is updated.
+
+	// PushView makes v the top view, hiding the other views.
+	PushView(v View, t Transition)
+
+	// PushOverlay makes v the top view, drawn over the other views, e.g. a
+	// dialog.
+	PushOverlay(v View, t Transition)
+
+	// PopView removes the top view, unless it is the only one.
+	PopView(t Transition)
+
+	// ReplaceView replaces the top view with v.
+	ReplaceView(v View, t Transition)
 }
 
 type Game struct {
 	outsideWidth  int
 	outsideHeight int
-	currentView   View
-	nextView      View
+	views         []stackedView // The last one is the active view
+	activeView    View          // Last view told it is active
+	changes       []viewChange  // Made at the start of the next update
+	transition    *runningTransition
 	mx            sync.Mutex
 	pointer       PointerTracker
+	keyboard      Keyboard
+	gestures      GestureTracker
+	input         InputSource // EbitenInput if nil
 }
 
 var _ ebiten.Game = (*Game)(nil)
@@ -32,28 +55,85 @@ var _ ViewContainer = (*Game)(nil)
 
 func NewGame(initialView View) *Game {
 	return &Game{
-		currentView: initialView,
+		views: []stackedView{{view: initialView}},
 	}
 }
 
 func (g *Game) Update() error {
-	g.pointer.Update()
-	g.mx.Lock()
-	if g.nextView != nil {
-		g.currentView = g.nextView
-		g.nextView = nil
+	if g.input == nil {
+		g.input = EbitenInput{}
+	}
+	g.input.Update()
+	g.pointer.Update(g.input)
+	g.keyboard.Update(g.input)
+	g.gestures.Update(g.input)
+	if g.gestures.IsPinching() {
+		// The fingers are panning or zooming, not editing
 		g.pointer.CancelTouch()
 	}
-	g.mx.Unlock()
-	if g.currentView != nil {
-		return g.currentView.Update(g)
+	g.applyViewChanges()
+	if t := g.transition; t != nil {
+		// Touches started during the transition are ignored
+		g.pointer.CancelTouch()
+		t.frame++
+		if !t.done() {
+			return nil
+		}
+		t.dispose()
+		g.transition = nil
+	}
+	if v := topView(g.views); v != nil {
+		return v.Update(g)
 	}
 	return nil
 }
 
+// applyViewChanges makes the changes to the view stack requested since the
+// last update, and tells the views if the active view changes.
+func (g *Game) applyViewChanges() {
+	g.mx.Lock()
+	changes := g.changes
+	g.changes = nil
+	g.mx.Unlock()
+	if len(changes) > 0 {
+		// Copied as the changes may reuse the backing array
+		from := append([]stackedView(nil), g.views...)
+		transition := Transition{}
+		for _, c := range changes {
+			g.views = c.apply(g.views)
+			if c.transition.Kind != NoTransition {
+				transition = c.transition
+			}
+		}
+		if transition.Kind != NoTransition && !sameViews(from, g.views) {
+			if g.transition != nil {
+				g.transition.dispose()
+			}
+			g.transition = &runningTransition{Transition: transition, from: from}
+		}
+	}
+	v := topView(g.views)
+	if v == g.activeView {
+		return
+	}
+	if g.activeView != nil {
+		// The touch was meant for the previous view
+		g.pointer.CancelTouch()
+		if h, ok := g.activeView.(ExitHandler); ok {
+			h.OnExit(g)
+		}
+	}
+	g.activeView = v
+	if h, ok := v.(EnterHandler); ok {
+		h.OnEnter(g)
+	}
+}
+
 func (g *Game) Draw(screen *ebiten.Image) {
-	if g.currentView != nil {
-		g.currentView.Draw(screen)
+	if g.transition != nil {
+		g.transition.draw(screen, g.views)
+	} else {
+		drawViews(screen, g.views)
 	}
 }
 
@@ -75,8 +155,70 @@ func (g *Game) Pointer() *PointerTracker {
 	return &g.pointer
 }
 
+func (g *Game) Keyboard() *Keyboard {
+	return &g.keyboard
+}
+
+func (g *Game) Gestures() *GestureTracker {
+	return &g.gestures
+}
+
+// SetInput replaces the source of the input, which is EbitenInput by
+// default.
+func (g *Game) SetInput(in InputSource) {
+	g.input = in
+}
+
+// SetView makes v the only view, without a transition.
 func (g *Game) SetView(v View) {
+	g.changeViews(Transition{}, func([]stackedView) []stackedView {
+		return []stackedView{{view: v}}
+	})
+}
+
+func (g *Game) PushView(v View, t Transition) {
+	g.changeViews(t, func(views []stackedView) []stackedView {
+		return append(views, stackedView{view: v})
+	})
+}
+
+func (g *Game) PushOverlay(v View, t Transition) {
+	g.changeViews(t, func(views []stackedView) []stackedView {
+		return append(views, stackedView{view: v, overlay: true})
+	})
+}
+
+func (g *Game) PopView(t Transition) {
+	g.changeViews(t, func(views []stackedView) []stackedView {
+		if len(views) <= 1 {
+			return views
+		}
+		return views[:len(views)-1]
+	})
+}
+
+func (g *Game) ReplaceView(v View, t Transition) {
+	g.changeViews(t, func(views []stackedView) []stackedView {
+		if len(views) == 0 {
+			return []stackedView{{view: v}}
+		}
+		// Replacing an overlay makes another overlay
+		top := views[len(views)-1]
+		return append(views[:len(views)-1:len(views)-1], stackedView{view: v, overlay: top.overlay})
+	})
+}
+
+// Views returns the views in the stack, the top view last.
+func (g *Game) Views() []View {
+	views := make([]View, len(g.views))
+	for i, v := range g.views {
+		views[i] = v.view
+	}
+	return views
+}
+
+func (g *Game) changeViews(t Transition, apply func([]stackedView) []stackedView) {
 	g.mx.Lock()
-	g.nextView = v
+	g.changes = append(g.changes, viewChange{apply: apply, transition: t})
 	g.mx.Unlock()
 }
@@ -0,0 +1,180 @@
+package engine
+
+import (
+	"image"
+	"reflect"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// A testView logs its updates and when it is entered and exited.
+type testView struct {
+	name string
+	log  *[]string
+}
+
+func (v *testView) Update(ViewContainer) error {
+	*v.log = append(*v.log, v.name)
+	return nil
+}
+
+func (v *testView) Draw(*ebiten.Image) {}
+
+func (v *testView) OnEnter(ViewContainer) {
+	*v.log = append(*v.log, "enter "+v.name)
+}
+
+func (v *testView) OnExit(ViewContainer) {
+	*v.log = append(*v.log, "exit "+v.name)
+}
+
+func TestGame_ViewStack(t *testing.T) {
+	var log []string
+	a, b, c := &testView{"a", &log}, &testView{"b", &log}, &testView{"c", &log}
+	slide := Transition{Kind: SlideLeft, Frames: 3}
+	tests := []struct {
+		name   string
+		change func(g *Game)
+		frames int
+		log    []string
+		views  []View
+	}{
+		{
+			name:   "Start",
+			change: func(*Game) {},
+			frames: 2,
+			log:    []string{"enter a", "a", "a"},
+			views:  []View{a},
+		},
+		{
+			name:   "Push",
+			change: func(g *Game) { g.PushView(b, Transition{}) },
+			frames: 1,
+			log:    []string{"exit a", "enter b", "b"},
+			views:  []View{a, b},
+		},
+		{
+			name:   "Overlay",
+			change: func(g *Game) { g.PushOverlay(c, Transition{}) },
+			frames: 1,
+			log:    []string{"exit b", "enter c", "c"},
+			views:  []View{a, b, c},
+		},
+		{
+			name:   "Replace",
+			change: func(g *Game) { g.ReplaceView(a, Transition{}) },
+			frames: 1,
+			log:    []string{"exit c", "enter a", "a"},
+			views:  []View{a, b, a},
+		},
+		{
+			// Views are not updated during transitions
+			name:   "Pop with a transition",
+			change: func(g *Game) { g.PopView(slide) },
+			frames: 4,
+			log:    []string{"exit a", "enter b", "b", "b"},
+			views:  []View{a, b},
+		},
+		{
+			name: "Several changes",
+			change: func(g *Game) {
+				g.PopView(Transition{})
+				g.PushView(c, Transition{})
+				g.PopView(Transition{})
+			},
+			frames: 1,
+			log:    []string{"exit b", "enter a", "a"},
+			views:  []View{a},
+		},
+		{
+			name:   "Pop the last view",
+			change: func(g *Game) { g.PopView(slide) },
+			frames: 1,
+			log:    []string{"a"},
+			views:  []View{a},
+		},
+		{
+			name:   "Set",
+			change: func(g *Game) { g.SetView(c) },
+			frames: 1,
+			log:    []string{"exit a", "enter c", "c"},
+			views:  []View{c},
+		},
+	}
+	g := NewGame(a)
+	g.SetInput(NewScriptedInput(nil))
+	for _, test := range tests {
+		log = nil
+		test.change(g)
+		for i := 0; i < test.frames; i++ {
+			if err := g.Update(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !reflect.DeepEqual(log, test.log) {
+			t.Errorf("%s: got log %q, want %q", test.name, log, test.log)
+		}
+		if views := g.Views(); !reflect.DeepEqual(views, test.views) {
+			t.Errorf("%s: got %d views, want %d", test.name, len(views), len(test.views))
+		}
+	}
+}
+
+func TestDialog(t *testing.T) {
+	tests := []struct {
+		name   string
+		script func(d *Dialog) InputScript
+		chosen int
+	}{
+		{
+			name: "Click",
+			script: func(d *Dialog) InputScript {
+				p := d.buttonRects[1].Min.Add(image.Pt(5, 5))
+				return InputScript{}.Click(p.X, p.Y)
+			},
+			chosen: 1,
+		},
+		{
+			name: "Enter",
+			script: func(*Dialog) InputScript {
+				return InputScript{}.Press(ebiten.KeyArrowRight).Press(ebiten.KeyArrowRight).Press(ebiten.KeyEnter)
+			},
+			chosen: 2,
+		},
+		{
+			name: "Escape",
+			script: func(*Dialog) InputScript {
+				return InputScript{}.Press(ebiten.KeyEscape)
+			},
+			chosen: 2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var log []string
+			chosen := -1
+			d := NewDialog("Really?", []string{"Yes", "No", "Cancel"}, func(i int) { chosen = i })
+			g := NewGame(&testView{"a", &log})
+			g.Layout(640, 480)
+			in := NewScriptedInput(InputScript{}.Wait(1))
+			g.SetInput(in)
+			ShowDialog(g, d)
+			for i := 0; i < dialogTransition.Frames+1; i++ {
+				g.Update()
+			}
+			in = NewScriptedInput(test.script(d))
+			g.SetInput(in)
+			for !in.Done() {
+				g.Update()
+			}
+			if chosen != test.chosen {
+				t.Errorf("got button %d, want %d", chosen, test.chosen)
+			}
+			g.Update()
+			if len(g.Views()) != 1 {
+				t.Error("expected the dialog to be closed")
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// How much one step of the mouse wheel zooms in or out.
+const wheelZoomFactor = 1.1
+
+// A GestureTracker tracks the gestures used to pan and zoom: the mouse wheel,
+// dragging with the right or middle mouse button and touching with two
+// fingers (pinch to zoom, move both fingers to pan).
+type GestureTracker struct {
+	dragging bool        // Panning with the mouse
+	pinching bool        // Touching with two fingers
+	startPos image.Point // Where the current gesture started
+	lastX    float64     // Last position of the gesture
+	lastY    float64
+	lastDist float64 // Last distance between the two fingers
+
+	// Changes in the current frame
+	zoom         float64
+	zoomX, zoomY float64
+	panX, panY   float64
+}
+
+func (g *GestureTracker) Update(in InputSource) {
+	g.zoom, g.panX, g.panY = 1, 0, 0
+	cx, cy := in.CursorPosition()
+	if _, wy := in.Wheel(); wy != 0 && !g.pinching {
+		g.zoom = math.Pow(wheelZoomFactor, wy)
+		g.zoomX, g.zoomY = float64(cx), float64(cy)
+		if !g.dragging {
+			g.startPos = image.Pt(cx, cy)
+		}
+	}
+	if touchIDs := in.TouchIDs(); len(touchIDs) >= 2 {
+		x1, y1 := in.TouchPosition(touchIDs[0])
+		x2, y2 := in.TouchPosition(touchIDs[1])
+		x, y := float64(x1+x2)/2, float64(y1+y2)/2
+		dist := math.Hypot(float64(x2-x1), float64(y2-y1))
+		if g.pinching {
+			if g.lastDist > 0 && dist > 0 {
+				g.zoom = dist / g.lastDist
+			}
+			g.zoomX, g.zoomY = x, y
+			g.panX, g.panY = x-g.lastX, y-g.lastY
+		} else {
+			g.startPos = image.Pt(int(x), int(y))
+		}
+		g.pinching, g.dragging = true, false
+		g.lastX, g.lastY, g.lastDist = x, y, dist
+		return
+	}
+	g.pinching = false
+	if in.IsMouseButtonPressed(ebiten.MouseButtonRight) || in.IsMouseButtonPressed(ebiten.MouseButtonMiddle) {
+		x, y := float64(cx), float64(cy)
+		if g.dragging {
+			g.panX, g.panY = x-g.lastX, y-g.lastY
+		} else {
+			g.startPos = image.Pt(cx, cy)
+		}
+		g.dragging = true
+		g.lastX, g.lastY = x, y
+		return
+	}
+	g.dragging = false
+}
+
+// IsPinching returns true if two fingers touch the screen, in which case
+// touches should not be used for anything else.
+func (g *GestureTracker) IsPinching() bool {
+	return g != nil && g.pinching
+}
+
+// StartPos returns where the current gesture started, which is also where the
+// mouse wheel was used.
+func (g *GestureTracker) StartPos() image.Point {
+	if g == nil {
+		return image.Point{}
+	}
+	return g.startPos
+}
+
+// Zoom returns the zoom factor for this frame (1 for no zoom) and the point
+// in screen coordinates to zoom around.
+func (g *GestureTracker) Zoom() (float64, float64, float64) {
+	if g == nil || g.zoom == 0 {
+		return 1, 0, 0
+	}
+	return g.zoom, g.zoomX, g.zoomY
+}
+
+// Pan returns by how much to pan for this frame, in screen coordinates.
+func (g *GestureTracker) Pan() (float64, float64) {
+	if g == nil {
+		return 0, 0
+	}
+	return g.panX, g.panY
+}
@@ -0,0 +1,292 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Version of the input recording format written by InputRecordingToJSON.
+const inputRecordingVersion = 1
+
+// An InputRecording is a record of the input of a game session, frame by
+// frame.  Replaying it on a screen of the same size reproduces exactly what
+// happened, e.g. to reproduce a bug in the board editor.
+type InputRecording struct {
+	Version int          `json:"version"`
+	Width   int          `json:"width"` // Size of the screen
+	Height  int          `json:"height"`
+	Frames  int          `json:"frames"` // Number of frames recorded
+	Events  []InputEvent `json:"events"`
+}
+
+// An InputEvent is a change of the input: the input stays the same from its
+// frame until the frame of the next event.  Nothing is pressed before the
+// first event.
+type InputEvent struct {
+	Frame   int      `json:"frame"`
+	Buttons []string `json:"buttons,omitempty"` // "left", "right" or "middle"
+	X       int      `json:"x"`
+	Y       int      `json:"y"`
+	WheelX  float64  `json:"wheelX,omitempty"`
+	WheelY  float64  `json:"wheelY,omitempty"`
+	Touches []Touch  `json:"touches,omitempty"`
+	Keys    []string `json:"keys,omitempty"` // As returned by ebiten.Key.String
+}
+
+var mouseButtonNames = map[ebiten.MouseButton]string{
+	ebiten.MouseButtonLeft:   "left",
+	ebiten.MouseButtonRight:  "right",
+	ebiten.MouseButtonMiddle: "middle",
+}
+
+var mouseButtonsByName = map[string]ebiten.MouseButton{}
+
+// Only keys that say which side they are on are recorded, e.g. "ShiftLeft"
+// but not "Shift".
+var keysByName = map[string]ebiten.Key{}
+
+func init() {
+	for b, name := range mouseButtonNames {
+		mouseButtonsByName[name] = b
+	}
+	for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+		if _, ok := keysEitherSide[k]; !ok {
+			keysByName[k.String()] = k
+		}
+	}
+}
+
+// Script returns the input of each frame of the recording.
+func (r *InputRecording) Script() (InputScript, error) {
+	script := make(InputScript, r.Frames)
+	for i, e := range r.Events {
+		if e.Frame < 0 || e.Frame >= r.Frames || i > 0 && e.Frame <= r.Events[i-1].Frame {
+			return nil, fmt.Errorf("event %d: frame %d out of order", i+1, e.Frame)
+		}
+		f, err := e.inputFrame()
+		if err != nil {
+			return nil, fmt.Errorf("event %d: %s", i+1, err)
+		}
+		end := r.Frames
+		if i < len(r.Events)-1 {
+			end = r.Events[i+1].Frame
+		}
+		for j := e.Frame; j < end && j < r.Frames; j++ {
+			script[j] = f
+		}
+	}
+	return script, nil
+}
+
+func (e *InputEvent) inputFrame() (InputFrame, error) {
+	f := InputFrame{
+		X:       e.X,
+		Y:       e.Y,
+		WheelX:  e.WheelX,
+		WheelY:  e.WheelY,
+		Touches: e.Touches,
+	}
+	for _, name := range e.Buttons {
+		b, ok := mouseButtonsByName[name]
+		if !ok {
+			return f, fmt.Errorf("unknown mouse button %q", name)
+		}
+		f.Buttons = append(f.Buttons, b)
+	}
+	for _, name := range e.Keys {
+		k, ok := keysByName[name]
+		if !ok {
+			return f, fmt.Errorf("unknown key %q", name)
+		}
+		f.Keys = append(f.Keys, k)
+	}
+	return f, nil
+}
+
+func newInputEvent(frame int, f InputFrame) InputEvent {
+	e := InputEvent{
+		Frame:   frame,
+		X:       f.X,
+		Y:       f.Y,
+		WheelX:  f.WheelX,
+		WheelY:  f.WheelY,
+		Touches: f.Touches,
+	}
+	for _, b := range f.Buttons {
+		e.Buttons = append(e.Buttons, mouseButtonNames[b])
+	}
+	for _, k := range f.Keys {
+		e.Keys = append(e.Keys, k.String())
+	}
+	return e
+}
+
+// In JSON format, a recording has one object per line so that it can be
+// written as the input comes and still be replayed if the game crashes: a
+// header with the version and the size of the screen, then the events, then
+// the number of frames.  Without the last line, the recording stops after
+// the frame of the last event.
+type inputRecordingHeader struct {
+	Version int `json:"version"`
+	Width   int `json:"width"`
+	Height  int `json:"height"`
+}
+
+type inputRecordingEnd struct {
+	Frames int `json:"frames"`
+}
+
+// Any line after the header.
+type inputRecordingLine struct {
+	InputEvent
+	Frames *int `json:"frames"`
+}
+
+// InputRecordingFromJSON parses a recording written by InputRecordingToJSON
+// or an InputRecorder, even if its last line is missing.
+func InputRecordingFromJSON(data []byte) (*InputRecording, error) {
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	var header inputRecordingHeader
+	if err := json.Unmarshal(lines[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid input recording: line 1: %s", err)
+	}
+	if header.Version > inputRecordingVersion {
+		return nil, fmt.Errorf("unsupported input recording format version %d", header.Version)
+	}
+	r := InputRecording{Version: header.Version, Width: header.Width, Height: header.Height}
+	for i, data := range lines[1:] {
+		var line inputRecordingLine
+		if err := json.Unmarshal(data, &line); err != nil {
+			return nil, fmt.Errorf("invalid input recording: line %d: %s", i+2, err)
+		}
+		if line.Frames != nil {
+			r.Frames = *line.Frames
+			break
+		}
+		r.Events = append(r.Events, line.InputEvent)
+		r.Frames = line.Frame + 1
+	}
+	if _, err := r.Script(); err != nil {
+		return nil, fmt.Errorf("invalid input recording: %s", err)
+	}
+	return &r, nil
+}
+
+// InputRecordingToJSON returns the recording in JSON format.
+func InputRecordingToJSON(r *InputRecording) ([]byte, error) {
+	var b bytes.Buffer
+	if err := writeJSONLine(&b, inputRecordingHeader{r.Version, r.Width, r.Height}); err != nil {
+		return nil, err
+	}
+	for _, e := range r.Events {
+		if err := writeJSONLine(&b, e); err != nil {
+			return nil, err
+		}
+	}
+	if err := writeJSONLine(&b, inputRecordingEnd{r.Frames}); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func writeJSONLine(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// An InputRecorder is an input source that records the input of another
+// source as it is read.  It can also write the recording as it goes, so that
+// it is not lost if the game crashes.
+type InputRecorder struct {
+	InputSource
+	recording InputRecording
+	last      InputFrame
+	w         io.Writer // Where to write the events, if not nil
+	err       error     // First error writing to w
+}
+
+var _ InputSource = (*InputRecorder)(nil)
+
+func NewInputRecorder(source InputSource) *InputRecorder {
+	return &InputRecorder{
+		InputSource: source,
+		recording:   InputRecording{Version: inputRecordingVersion},
+	}
+}
+
+// StartWriting starts writing the recording to w, in JSON format, for a screen of
+// the given size.  Each event is written when it is recorded.  Call Close to
+// finish the recording.
+func (r *InputRecorder) StartWriting(w io.Writer, width, height int) error {
+	r.recording.Width, r.recording.Height = width, height
+	r.w = w
+	r.write(inputRecordingHeader{r.recording.Version, width, height})
+	for _, e := range r.recording.Events {
+		r.write(e)
+	}
+	return r.err
+}
+
+// Close writes the number of frames recorded at the end of the recording,
+// and returns the first error writing it.
+func (r *InputRecorder) Close() error {
+	r.write(inputRecordingEnd{r.recording.Frames})
+	r.w = nil
+	return r.err
+}
+
+func (r *InputRecorder) write(v interface{}) {
+	if r.w != nil && r.err == nil {
+		r.err = writeJSONLine(r.w, v)
+	}
+}
+
+func (r *InputRecorder) Update() {
+	r.InputSource.Update()
+	f := captureInput(r.InputSource)
+	if !reflect.DeepEqual(f, r.last) {
+		e := newInputEvent(r.recording.Frames, f)
+		r.recording.Events = append(r.recording.Events, e)
+		r.write(e)
+		r.last = f
+	}
+	r.recording.Frames++
+}
+
+// Recording returns what has been recorded so far.
+func (r *InputRecorder) Recording() *InputRecording {
+	rec := r.recording
+	rec.Events = append([]InputEvent(nil), r.recording.Events...)
+	return &rec
+}
+
+// captureInput returns the current state of an input source.
+func captureInput(in InputSource) InputFrame {
+	var f InputFrame
+	for _, b := range []ebiten.MouseButton{ebiten.MouseButtonLeft, ebiten.MouseButtonRight, ebiten.MouseButtonMiddle} {
+		if in.IsMouseButtonPressed(b) {
+			f.Buttons = append(f.Buttons, b)
+		}
+	}
+	f.X, f.Y = in.CursorPosition()
+	f.WheelX, f.WheelY = in.Wheel()
+	for _, id := range in.TouchIDs() {
+		x, y := in.TouchPosition(id)
+		f.Touches = append(f.Touches, Touch{ID: id, X: x, Y: y})
+	}
+	for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+		if _, ok := keysEitherSide[k]; !ok && in.IsKeyPressed(k) {
+			f.Keys = append(f.Keys, k)
+		}
+	}
+	return f
+}
@@ -0,0 +1,50 @@
+package engine
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// An InputSource gives the state of the mouse, touches and keys in the
+// current frame.  PointerTracker, Keyboard and GestureTracker read the input
+// through it, so that it can be scripted in tests.
+type InputSource interface {
+	// Update is called at the start of each frame.
+	Update()
+
+	IsMouseButtonPressed(ebiten.MouseButton) bool
+	CursorPosition() (int, int)
+	Wheel() (float64, float64)
+	TouchIDs() []ebiten.TouchID
+	TouchPosition(ebiten.TouchID) (int, int)
+	IsKeyPressed(ebiten.Key) bool
+}
+
+// EbitenInput is the input of the game window, which is the default input
+// source.
+type EbitenInput struct{}
+
+var _ InputSource = EbitenInput{}
+
+func (EbitenInput) Update() {}
+
+func (EbitenInput) IsMouseButtonPressed(b ebiten.MouseButton) bool {
+	return ebiten.IsMouseButtonPressed(b)
+}
+
+func (EbitenInput) CursorPosition() (int, int) {
+	return ebiten.CursorPosition()
+}
+
+func (EbitenInput) Wheel() (float64, float64) {
+	return ebiten.Wheel()
+}
+
+func (EbitenInput) TouchIDs() []ebiten.TouchID {
+	return ebiten.TouchIDs()
+}
+
+func (EbitenInput) TouchPosition(id ebiten.TouchID) (int, int) {
+	return ebiten.TouchPosition(id)
+}
+
+func (EbitenInput) IsKeyPressed(k ebiten.Key) bool {
+	return ebiten.IsKeyPressed(k)
+}
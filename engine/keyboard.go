@@ -0,0 +1,56 @@
+package engine
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// Number of frames a key must be held before it starts repeating, and number
+// of frames between repeats.
+const (
+	keyRepeatDelay    = 30
+	keyRepeatInterval = 6
+)
+
+// A Keyboard tracks the keys pressed, like PointerTracker does for the mouse
+// and touches.
+type Keyboard struct {
+	frames [ebiten.KeyMax + 1]int // For how many frames each key is pressed
+}
+
+func (k *Keyboard) Update(in InputSource) {
+	for key := range k.frames {
+		if in.IsKeyPressed(ebiten.Key(key)) {
+			k.frames[key]++
+		} else {
+			k.frames[key] = 0
+		}
+	}
+}
+
+// IsPressed returns true if the key is down.
+func (k *Keyboard) IsPressed(key ebiten.Key) bool {
+	return k.duration(key) > 0
+}
+
+// JustPressed returns true if the key has been pressed in this frame.
+func (k *Keyboard) JustPressed(key ebiten.Key) bool {
+	return k.duration(key) == 1
+}
+
+// Repeated returns true if the key has been pressed in this frame, or is
+// held and repeats in this frame.
+func (k *Keyboard) Repeated(key ebiten.Key) bool {
+	d := k.duration(key)
+	return d == 1 || d >= keyRepeatDelay && (d-keyRepeatDelay)%keyRepeatInterval == 0
+}
+
+// HasCommandModifier returns true if Ctrl or Cmd is down, in which case keys
+// are usually shortcuts for the browser or the system.
+func (k *Keyboard) HasCommandModifier() bool {
+	return k.IsPressed(ebiten.KeyControl) || k.IsPressed(ebiten.KeyMeta)
+}
+
+func (k *Keyboard) duration(key ebiten.Key) int {
+	if k == nil || key < 0 || int(key) >= len(k.frames) {
+		return 0
+	}
+	return k.frames[key]
+}
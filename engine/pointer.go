@@ -15,6 +15,11 @@ const (
 	TouchUp
 )
 
+// Number of frames a finger must touch the screen before the touch counts, in
+// case a second finger comes down to pan or zoom (see GestureTracker).  A
+// shorter tap still counts when the finger is lifted.
+const touchDelayFrames = 6
+
 type PointerTracker struct {
 	startPos    image.Point
 	lastPos     image.Point
@@ -22,6 +27,10 @@ type PointerTracker struct {
 	status      TouchStatus
 	frames      int
 	cancelTouch bool
+
+	// A touch that does not count yet
+	waitingFrames int // Frames it has been waiting, 0 if none
+	waitingPos    image.Point
 }
 
 func (p *PointerTracker) CancelTouch() {
@@ -30,6 +39,7 @@ func (p *PointerTracker) CancelTouch() {
 	}
 	p.cancelTouch = true
 	p.status = NoTouch
+	p.waitingFrames = 0
 }
 
 func (p *PointerTracker) Status() TouchStatus {
@@ -68,14 +78,23 @@ func (p *PointerTracker) AdvanceStartPos() {
 	p.startPos = p.lastPos
 }
 
-func (p *PointerTracker) Update() {
+func (p *PointerTracker) Update(in InputSource) {
 	var currentPos image.Point
-	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
-		currentPos = image.Pt(ebiten.CursorPosition())
-	} else if touchIDs := ebiten.TouchIDs(); touchIDs != nil {
-		currentPos = image.Pt(ebiten.TouchPosition(touchIDs[0]))
+	if in.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		currentPos = image.Pt(in.CursorPosition())
+	} else if touchIDs := in.TouchIDs(); touchIDs != nil {
+		currentPos = image.Pt(in.TouchPosition(touchIDs[0]))
+		if !p.cancelTouch && (p.status == NoTouch || p.status == TouchUp) {
+			p.waitForTouch(currentPos)
+			return
+		}
 	} else {
 		p.cancelTouch = false
+		if p.waitingFrames > 0 {
+			// A tap
+			p.touchDown(p.waitingPos)
+			return
+		}
 		switch p.status {
 		case NoTouch:
 			// Nothing to do?
@@ -93,10 +112,8 @@ func (p *PointerTracker) Update() {
 	}
 	switch p.status {
 	case NoTouch, TouchUp:
-		p.status = TouchDown
-		p.startPos = currentPos
-		p.lastPos = currentPos
-		p.frames = 1
+		p.touchDown(currentPos)
+		return
 	case TouchDown:
 		p.status = Dragging
 		fallthrough
@@ -114,6 +131,28 @@ func (p *PointerTracker) Update() {
 	return
 }
 
+// waitForTouch counts a touch only once it has lasted touchDelayFrames.  It
+// then goes down where the finger first touched the screen.
+func (p *PointerTracker) waitForTouch(pos image.Point) {
+	p.status = NoTouch
+	if p.waitingFrames == 0 {
+		p.waitingPos = pos
+	}
+	p.waitingFrames++
+	if p.waitingFrames > touchDelayFrames {
+		p.touchDown(p.waitingPos)
+	}
+}
+
+func (p *PointerTracker) touchDown(pos image.Point) {
+	p.status = TouchDown
+	p.startPos = pos
+	p.lastPos = pos
+	p.currentPos = pos
+	p.frames = 1
+	p.waitingFrames = 0
+}
+
 func (p *PointerTracker) ForWindow(w *Window) PointerStatus {
 	if !w.Contains(p.currentPos) {
 		return PointerStatus{}
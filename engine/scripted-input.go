@@ -0,0 +1,185 @@
+package engine
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// An InputFrame is the state of the input in one frame.
+type InputFrame struct {
+	Buttons        []ebiten.MouseButton // Mouse buttons pressed
+	X, Y           int                  // Position of the mouse cursor
+	WheelX, WheelY float64
+	Touches        []Touch
+	Keys           []ebiten.Key // Keys pressed
+}
+
+// A Touch is a finger on the screen.
+type Touch struct {
+	ID ebiten.TouchID `json:"id"`
+	X  int            `json:"x"`
+	Y  int            `json:"y"`
+}
+
+// An InputScript is a list of input frames.  Its methods add frames for
+// common gestures, e.g.
+//
+//	InputScript{}.Click(10, 20).Wait(5).Press(ebiten.KeySpace)
+type InputScript []InputFrame
+
+// Wait adds n frames where nothing is pressed.
+func (s InputScript) Wait(n int) InputScript {
+	x, y := s.cursor()
+	for i := 0; i < n; i++ {
+		s = append(s, InputFrame{X: x, Y: y})
+	}
+	return s
+}
+
+// Click adds a click of the left mouse button at (x, y).
+func (s InputScript) Click(x, y int) InputScript {
+	return append(s,
+		InputFrame{Buttons: []ebiten.MouseButton{ebiten.MouseButtonLeft}, X: x, Y: y},
+		InputFrame{X: x, Y: y},
+	)
+}
+
+// Drag adds a drag with the left mouse button from (x1, y1) to (x2, y2) in
+// the given number of steps, one per frame.
+func (s InputScript) Drag(x1, y1, x2, y2, steps int) InputScript {
+	return s.DragThrough(steps, image.Pt(x1, y1), image.Pt(x2, y2))
+}
+
+// DragThrough adds a drag with the left mouse button going through the points,
+// in the given number of steps between two points.
+func (s InputScript) DragThrough(steps int, points ...image.Point) InputScript {
+	if len(points) == 0 {
+		return s
+	}
+	if steps < 1 {
+		steps = 1
+	}
+	left := []ebiten.MouseButton{ebiten.MouseButtonLeft}
+	s = append(s, InputFrame{Buttons: left, X: points[0].X, Y: points[0].Y})
+	for i := 1; i < len(points); i++ {
+		p, q := points[i-1], points[i]
+		for j := 1; j <= steps; j++ {
+			s = append(s, InputFrame{
+				Buttons: left,
+				X:       p.X + (q.X-p.X)*j/steps,
+				Y:       p.Y + (q.Y-p.Y)*j/steps,
+			})
+		}
+	}
+	last := points[len(points)-1]
+	return append(s, InputFrame{X: last.X, Y: last.Y})
+}
+
+// Press adds a frame where the keys are pressed together, then a frame where
+// they are released.
+func (s InputScript) Press(keys ...ebiten.Key) InputScript {
+	x, y := s.cursor()
+	return append(s, InputFrame{X: x, Y: y, Keys: keys}, InputFrame{X: x, Y: y})
+}
+
+func (s InputScript) cursor() (int, int) {
+	if len(s) == 0 {
+		return 0, 0
+	}
+	f := s[len(s)-1]
+	return f.X, f.Y
+}
+
+// A ScriptedInput is an input source that plays input frames one by one.
+// After the last frame, nothing is pressed unless ThenUse was called.
+type ScriptedInput struct {
+	frames  []InputFrame
+	next    int
+	current InputFrame
+	after   InputSource // Read after the last frame, if not nil
+}
+
+var _ InputSource = (*ScriptedInput)(nil)
+
+func NewScriptedInput(frames []InputFrame) *ScriptedInput {
+	return &ScriptedInput{frames: frames}
+}
+
+// Done returns true if all the frames have been played.
+func (s *ScriptedInput) Done() bool {
+	return s.next >= len(s.frames)
+}
+
+// ThenUse makes the input come from another source once all the frames have
+// been played, e.g. to carry on playing after a replay.
+func (s *ScriptedInput) ThenUse(in InputSource) *ScriptedInput {
+	s.after = in
+	return s
+}
+
+func (s *ScriptedInput) Update() {
+	if s.Done() {
+		if s.after != nil {
+			s.after.Update()
+			s.current = captureInput(s.after)
+		} else {
+			s.current = InputFrame{X: s.current.X, Y: s.current.Y}
+		}
+		return
+	}
+	s.current = s.frames[s.next]
+	s.next++
+}
+
+func (s *ScriptedInput) IsMouseButtonPressed(b ebiten.MouseButton) bool {
+	for _, pressed := range s.current.Buttons {
+		if pressed == b {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ScriptedInput) CursorPosition() (int, int) {
+	return s.current.X, s.current.Y
+}
+
+func (s *ScriptedInput) Wheel() (float64, float64) {
+	return s.current.WheelX, s.current.WheelY
+}
+
+func (s *ScriptedInput) TouchIDs() []ebiten.TouchID {
+	var ids []ebiten.TouchID
+	for _, t := range s.current.Touches {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+func (s *ScriptedInput) TouchPosition(id ebiten.TouchID) (int, int) {
+	for _, t := range s.current.Touches {
+		if t.ID == id {
+			return t.X, t.Y
+		}
+	}
+	return 0, 0
+}
+
+// Either key of a pair stands for the key that does not say which side.
+var keysEitherSide = map[ebiten.Key][2]ebiten.Key{
+	ebiten.KeyControl: {ebiten.KeyControlLeft, ebiten.KeyControlRight},
+	ebiten.KeyShift:   {ebiten.KeyShiftLeft, ebiten.KeyShiftRight},
+	ebiten.KeyAlt:     {ebiten.KeyAltLeft, ebiten.KeyAltRight},
+	ebiten.KeyMeta:    {ebiten.KeyMetaLeft, ebiten.KeyMetaRight},
+}
+
+func (s *ScriptedInput) IsKeyPressed(k ebiten.Key) bool {
+	sides, hasSides := keysEitherSide[k]
+	for _, pressed := range s.current.Keys {
+		if pressed == k || hasSides && (pressed == sides[0] || pressed == sides[1]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,224 @@
+package engine
+
+import (
+	"bytes"
+	"image"
+	"reflect"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestScriptedInput_Pointer(t *testing.T) {
+	in := NewScriptedInput(InputScript{}.Drag(0, 0, 20, 10, 2).Click(5, 5))
+	want := []struct {
+		status TouchStatus
+		x, y   int
+	}{
+		{TouchDown, 0, 0},
+		{Dragging, 10, 5},
+		{Dragging, 20, 10},
+		{TouchUp, 20, 10},
+		{TouchDown, 5, 5},
+		{TouchUp, 5, 5},
+		{NoTouch, 5, 5},
+	}
+	var p PointerTracker
+	for i, w := range want {
+		in.Update()
+		p.Update(in)
+		if got := p.Status(); got != w.status {
+			t.Errorf("frame %d: got status %d, want %d", i, got, w.status)
+		}
+		if got := p.CurrentPos(); got.X != w.x || got.Y != w.y {
+			t.Errorf("frame %d: got position %v, want (%d, %d)", i, got, w.x, w.y)
+		}
+	}
+	if !in.Done() {
+		t.Error("expected the script to be done")
+	}
+}
+
+func TestPointerTracker_Touch(t *testing.T) {
+	type step struct {
+		touches []Touch
+		status  TouchStatus
+	}
+	var steps []step
+	add := func(n int, status TouchStatus, touches ...Touch) {
+		for i := 0; i < n; i++ {
+			steps = append(steps, step{touches, status})
+		}
+	}
+	// A tap counts when the finger is lifted
+	add(2, NoTouch, Touch{1, 5, 5})
+	add(1, TouchDown)
+	add(1, TouchUp)
+	// A touch long enough counts where it started
+	add(touchDelayFrames, NoTouch, Touch{2, 10, 10})
+	add(1, TouchDown, Touch{2, 12, 10})
+	add(1, Dragging, Touch{2, 14, 10})
+	add(1, TouchUp)
+	add(1, NoTouch)
+	// A second finger comes down before the touch counts
+	add(1, NoTouch, Touch{3, 20, 20})
+	add(2, NoTouch, Touch{3, 20, 20}, Touch{4, 30, 30})
+	add(touchDelayFrames+1, NoTouch, Touch{3, 20, 20})
+	add(1, NoTouch)
+
+	var script InputScript
+	for _, s := range steps {
+		script = append(script, InputFrame{Touches: s.touches})
+	}
+	in := NewScriptedInput(script)
+	var p PointerTracker
+	var g GestureTracker
+	var downs []image.Point
+	for i, s := range steps {
+		in.Update()
+		p.Update(in)
+		g.Update(in)
+		if g.IsPinching() {
+			p.CancelTouch()
+		}
+		if got := p.Status(); got != s.status {
+			t.Errorf("frame %d: got status %d, want %d", i, got, s.status)
+		}
+		if p.Status() == TouchDown {
+			downs = append(downs, p.CurrentPos())
+		}
+	}
+	if want := []image.Point{{5, 5}, {10, 10}}; !reflect.DeepEqual(downs, want) {
+		t.Errorf("got touches down at %v, want %v", downs, want)
+	}
+}
+
+func TestScriptedInput_Keyboard(t *testing.T) {
+	script := InputScript{}.Press(ebiten.KeyShiftRight, ebiten.KeyA)
+	for i := 0; i < keyRepeatDelay; i++ {
+		script = append(script, InputFrame{Keys: []ebiten.Key{ebiten.KeyA}})
+	}
+	in := NewScriptedInput(script)
+	var k Keyboard
+	update := func() {
+		in.Update()
+		k.Update(in)
+	}
+
+	update()
+	if !k.JustPressed(ebiten.KeyA) || !k.IsPressed(ebiten.KeyShift) || k.IsPressed(ebiten.KeyControl) {
+		t.Error("expected Shift+A to be just pressed")
+	}
+	update()
+	if k.IsPressed(ebiten.KeyA) || k.IsPressed(ebiten.KeyShift) {
+		t.Error("expected the keys to be released")
+	}
+	repeats := 0
+	for !in.Done() {
+		update()
+		if k.Repeated(ebiten.KeyA) {
+			repeats++
+		}
+	}
+	if repeats != 2 {
+		t.Errorf("got %d repeats, want 2", repeats)
+	}
+}
+
+func TestInputRecorder(t *testing.T) {
+	script := InputScript{}.Wait(2).Drag(0, 0, 20, 10, 2).Press(ebiten.KeyA, ebiten.KeyShiftRight)
+	script = append(script,
+		InputFrame{X: 20, Y: 10, WheelY: -1},
+		InputFrame{Touches: []Touch{{ID: 1, X: 3, Y: 4}, {ID: 2, X: 5, Y: 6}}},
+		InputFrame{Buttons: []ebiten.MouseButton{ebiten.MouseButtonRight}},
+	)
+	script = script.Wait(3)
+	rec := NewInputRecorder(NewScriptedInput(script))
+	for i := 0; i < len(script); i++ {
+		rec.Update()
+	}
+	recording := rec.Recording()
+	if got, want := len(recording.Events), 10; got != want {
+		t.Errorf("got %d events, want %d", got, want)
+	}
+	data, err := InputRecordingToJSON(recording)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recording, err = InputRecordingFromJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay, err := recording.Script()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replay) != len(script) {
+		t.Fatalf("got %d frames, want %d", len(replay), len(script))
+	}
+	for i, f := range replay {
+		if !reflect.DeepEqual(f, script[i]) {
+			t.Errorf("frame %d: got %+v, want %+v", i, f, script[i])
+		}
+	}
+}
+
+func TestInputRecorder_StartWriting(t *testing.T) {
+	script := InputScript{}.Click(1, 2).Wait(5)
+	rec := NewInputRecorder(NewScriptedInput(script))
+	var b bytes.Buffer
+	if err := rec.StartWriting(&b, 640, 480); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(script); i++ {
+		rec.Update()
+	}
+
+	// Without the last line, the recording ends after the last event
+	crashed, err := InputRecordingFromJSON(b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := rec.Recording()
+	if len(crashed.Events) != 2 || crashed.Frames != 2 || crashed.Width != 640 || crashed.Height != 480 {
+		t.Errorf("got %+v after a crash, want the events of %+v", crashed, want)
+	}
+
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := InputRecordingToJSON(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != string(data) {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), data)
+	}
+}
+
+func TestInputRecordingFromJSON_Errors(t *testing.T) {
+	tests := []struct {
+		name, json string
+	}{
+		{"Unknown key", `{"version": 1}
+{"frame": 0, "keys": ["Shift"]}`},
+		{"Unknown button", `{"version": 1}
+{"frame": 0, "buttons": ["back"]}`},
+		{"Out of order", `{"version": 1}
+{"frame": 3}
+{"frame": 1}`},
+		{"Past the end", `{"version": 1}
+{"frame": 2}
+{"frames": 2}`},
+		{"Version", `{"version": 2}`},
+		{"Not JSON", `{"version": 1}
+frame 2`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := InputRecordingFromJSON([]byte(test.json)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package engine
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// A view can implement EnterHandler and ExitHandler to be told when it
+// becomes the active view, i.e. the view at the top of the stack which gets
+// the input, and when it stops being it.
+type EnterHandler interface {
+	OnEnter(ViewContainer)
+}
+
+type ExitHandler interface {
+	OnExit(ViewContainer)
+}
+
+// A stackedView is a view in the view stack of a Game.  Overlays are drawn
+// over the views below them, other views hide them.
+type stackedView struct {
+	view    View
+	overlay bool
+}
+
+// A viewChange is a change to the view stack, made at the start of the next
+// update.
+type viewChange struct {
+	apply      func([]stackedView) []stackedView
+	transition Transition
+}
+
+// The top view of the stack.
+func topView(views []stackedView) View {
+	if len(views) == 0 {
+		return nil
+	}
+	return views[len(views)-1].view
+}
+
+func sameViews(views1, views2 []stackedView) bool {
+	if len(views1) != len(views2) {
+		return false
+	}
+	for i, v := range views1 {
+		if v != views2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// drawViews draws the top view and the views under it that are visible
+// through overlays.
+func drawViews(screen *ebiten.Image, views []stackedView) {
+	i := len(views) - 1
+	for i > 0 && views[i].overlay {
+		i--
+	}
+	for ; i >= 0 && i < len(views); i++ {
+		views[i].view.Draw(screen)
+	}
+}
+
+// TransitionKind says how the screen changes from one view to the next.
+type TransitionKind int
+
+const (
+	NoTransition TransitionKind = iota
+	Fade                        // The old views fade out as the new ones fade in
+	SlideLeft                   // The new views come in from the right
+	SlideRight                  // The new views come in from the left
+	SlideUp                     // The new views come in from the bottom
+	SlideDown                   // The new views come in from the top
+)
+
+// Number of frames a transition lasts if not set (a third of a second).
+const defaultTransitionFrames = 20
+
+// A Transition animates a change to the view stack.  The views do not get
+// updated during the transition.
+type Transition struct {
+	Kind   TransitionKind
+	Frames int // How long the transition lasts, defaultTransitionFrames if 0
+}
+
+func (t Transition) frames() int {
+	if t.Frames <= 0 {
+		return defaultTransitionFrames
+	}
+	return t.Frames
+}
+
+// A runningTransition is a transition being drawn, from the views as they
+// were before the change to the views as they are now.
+type runningTransition struct {
+	Transition
+	from      []stackedView
+	frame     int
+	fromImage *ebiten.Image
+	toImage   *ebiten.Image
+}
+
+// done returns true when the transition is complete.
+func (t *runningTransition) done() bool {
+	return t.frame >= t.frames()
+}
+
+func (t *runningTransition) draw(screen *ebiten.Image, to []stackedView) {
+	w, h := screen.Size()
+	t.fromImage = clearedImage(t.fromImage, w, h)
+	t.toImage = clearedImage(t.toImage, w, h)
+	drawViews(t.fromImage, t.from)
+	drawViews(t.toImage, to)
+
+	p := float64(t.frame) / float64(t.frames())
+	fw, fh := float64(w), float64(h)
+	var fromOp, toOp ebiten.DrawImageOptions
+	switch t.Kind {
+	case Fade:
+		fromOp.ColorM.Scale(1, 1, 1, 1-p)
+		toOp.ColorM.Scale(1, 1, 1, p)
+		// Adding up the two images keeps what they have in common as it is
+		toOp.CompositeMode = ebiten.CompositeModeLighter
+	case SlideLeft:
+		fromOp.GeoM.Translate(-p*fw, 0)
+		toOp.GeoM.Translate((1-p)*fw, 0)
+	case SlideRight:
+		fromOp.GeoM.Translate(p*fw, 0)
+		toOp.GeoM.Translate((p-1)*fw, 0)
+	case SlideUp:
+		fromOp.GeoM.Translate(0, -p*fh)
+		toOp.GeoM.Translate(0, (1-p)*fh)
+	case SlideDown:
+		fromOp.GeoM.Translate(0, p*fh)
+		toOp.GeoM.Translate(0, (p-1)*fh)
+	}
+	screen.DrawImage(t.fromImage, &fromOp)
+	screen.DrawImage(t.toImage, &toOp)
+}
+
+// clearedImage returns a cleared image of size w x h, reusing img if it has
+// the right size.
+func clearedImage(img *ebiten.Image, w, h int) *ebiten.Image {
+	if img != nil {
+		if iw, ih := img.Size(); iw == w && ih == h {
+			img.Clear()
+			return img
+		}
+		img.Dispose()
+	}
+	return ebiten.NewImage(w, h)
+}
+
+func (t *runningTransition) dispose() {
+	for _, img := range []*ebiten.Image{t.fromImage, t.toImage} {
+		if img != nil {
+			img.Dispose()
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Limits of the zoom of a viewport, relative to the size that fits the
+// window.
+const (
+	minZoom = 0.5
+	maxZoom = 8
+)
+
+// A Viewport is a zoom and pan applied to the content of a window on top of
+// fitting it in its bounds, updated with the gestures of a GestureTracker.
+// The zero value shows the content as CenteredWindow does.
+type Viewport struct {
+	zoom       float64 // 0 means 1
+	panX, panY float64 // In screen coordinates
+}
+
+// IsReset returns true if the content is neither zoomed nor panned.
+func (v *Viewport) IsReset() bool {
+	return v.zoom == 0 && v.panX == 0 && v.panY == 0
+}
+
+// Reset cancels the zoom and pan.
+func (v *Viewport) Reset() {
+	*v = Viewport{}
+}
+
+// Update applies the gestures that started inside the bounds of the window.
+func (v *Viewport) Update(bounds image.Rectangle, g *GestureTracker) {
+	if !g.StartPos().In(bounds) {
+		return
+	}
+	if f, x, y := g.Zoom(); f != 1 {
+		v.ZoomAt(bounds, f, x, y)
+	}
+	dx, dy := g.Pan()
+	v.panX += dx
+	v.panY += dy
+}
+
+// ZoomAt zooms by factor f around the point (x, y) in screen coordinates,
+// which stays in place.
+func (v *Viewport) ZoomAt(bounds image.Rectangle, f, x, y float64) {
+	z := v.scale()
+	nz := z * f
+	if nz < minZoom {
+		nz = minZoom
+	} else if nz > maxZoom {
+		nz = maxZoom
+	}
+	f = nz / z
+	cx, cy := center(bounds)
+	v.panX = (1-f)*(x-cx) + f*v.panX
+	v.panY = (1-f)*(y-cy) + f*v.panY
+	v.zoom = nz
+	if v.zoom == 1 && v.panX == 0 && v.panY == 0 {
+		v.zoom = 0
+	}
+}
+
+// Window returns a window like CenteredWindow, with the zoom and pan applied.
+// When zoomed or panned, the content can use all the bounds of the window.
+// Window.Coords still gives the coordinates of the content under a point.
+func (v *Viewport) Window(bounds image.Rectangle, drawBounds image.Rectangle, tr ebiten.GeoM) *Window {
+	w := CenteredWindow(bounds, drawBounds, tr)
+	if v.IsReset() {
+		return w
+	}
+	cx, cy := center(bounds)
+	w.tr.Translate(-cx, -cy)
+	w.tr.Scale(v.scale(), v.scale())
+	w.tr.Translate(cx+v.panX, cy+v.panY)
+	w.inv = w.tr
+	if w.inv.IsInvertible() {
+		w.inv.Invert()
+	}
+	w.drawBounds = bounds
+	return w
+}
+
+func (v *Viewport) scale() float64 {
+	if v.zoom == 0 {
+		return 1
+	}
+	return v.zoom
+}
+
+func center(r image.Rectangle) (float64, float64) {
+	return float64(r.Min.X+r.Max.X) / 2, float64(r.Min.Y+r.Max.Y) / 2
+}
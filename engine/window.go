@@ -41,6 +41,11 @@ func (w *Window) Canvas(screen *ebiten.Image) Canvas {
 	}
 }
 
+// OuterBounds returns the bounds the window was made to fit in.
+func (w *Window) OuterBounds() image.Rectangle {
+	return w.outerBounds
+}
+
 func (w *Window) Contains(pt image.Point) bool {
 	return pt.In(w.drawBounds)
 }
@@ -49,6 +54,12 @@ func (w *Window) Coords(pt image.Point) (float64, float64) {
 	return w.inv.Apply(fc(pt))
 }
 
+// ScreenPos returns the point of the screen showing the point (x, y) of the
+// content, the reverse of Coords.
+func (w *Window) ScreenPos(x, y float64) image.Point {
+	return pt(w.tr.Apply(x, y))
+}
+
 func CenteredWindow(bounds image.Rectangle, drawBounds image.Rectangle, tr ebiten.GeoM) *Window {
 	minX, minY := tr.Apply(fc(drawBounds.Min))
 	maxX, maxY := tr.Apply(fc(drawBounds.Max))
@@ -0,0 +1,119 @@
+package export
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+
+	"github.com/arnodel/gobot2flags/model"
+	"github.com/arnodel/gobot2flags/resources/images"
+)
+
+// GIFOptions control the animation made by RunGIF.
+type GIFOptions struct {
+	MaxSteps      int // Stop the run after that many steps (default 1000)
+	FramesPerStep int // Frames showing the robot carrying out a command (default 4)
+	Delay         int // Delay between frames in 100ths of a second (default 8)
+	EndDelay      int // Delay on the last frame in 100ths of a second (default 200)
+}
+
+// RunGIF runs the board on the level like model.RunLevel and returns an
+// animation of the run.
+func RunGIF(level *model.Level, board *model.CircuitBoard, opts GIFOptions) (*gif.GIF, model.RunResult) {
+	if opts.MaxSteps == 0 {
+		opts.MaxSteps = 1000
+	}
+	if opts.FramesPerStep == 0 {
+		opts.FramesPerStep = 4
+	}
+	if opts.Delay == 0 {
+		opts.Delay = 8
+	}
+	if opts.EndDelay == 0 {
+		opts.EndDelay = 200
+	}
+	var (
+		anim    gif.GIF
+		pal     = gifPalette()
+		bounds  = MazeBounds(level.Maze)
+		rgba    = image.NewRGBA(bounds)
+		step    int
+		addMaze = func(m *model.Maze, t float64, delay int) {
+			DrawMaze(rgba, m, t, step)
+			frame := image.NewPaletted(bounds, pal)
+			draw.Draw(frame, bounds, rgba, bounds.Min, draw.Src)
+			anim.Image = append(anim.Image, frame)
+			anim.Delay = append(anim.Delay, delay)
+		}
+	)
+	addMaze(level.Maze, 0, opts.Delay)
+	result := model.RunLevelFunc(level, board, opts.MaxSteps, func(c *model.LevelController) {
+		step++
+		if c.GameWon() || c.DeadEnd() {
+			return
+		}
+		for i := 0; i < opts.FramesPerStep; i++ {
+			addMaze(c.Maze(), float64(i)/float64(opts.FramesPerStep), opts.Delay)
+		}
+	})
+	if result.Maze.Robot() != nil {
+		result.Maze.StopRobot()
+	}
+	addMaze(result.Maze, 0, opts.EndDelay)
+	return &anim, result
+}
+
+// WriteRunGIF writes the animation made by RunGIF in GIF format.
+func WriteRunGIF(w io.Writer, level *model.Level, board *model.CircuitBoard, opts GIFOptions) (model.RunResult, error) {
+	anim, result := RunGIF(level, board, opts)
+	return result, gif.EncodeAll(w, anim)
+}
+
+// gifPalette returns the colors of the maze sprite sheets, the background
+// and the colors of floors being painted over other floors.
+func gifPalette() color.Palette {
+	pal := color.Palette{Background}
+	seen := map[color.Color]bool{color.RGBAModel.Convert(Background): true}
+	add := func(c color.Color) {
+		c = color.RGBAModel.Convert(c)
+		if !seen[c] && len(pal) < 256 {
+			seen[c] = true
+			pal = append(pal, c)
+		}
+	}
+	for _, name := range []string{"floors.png", "greywalls.png", "greenflag.png", "robot.png"} {
+		img := images.Get(name)
+		b := img.Bounds()
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				if _, _, _, a := img.At(x, y).RGBA(); a == 0xffff {
+					add(img.At(x, y))
+				}
+			}
+		}
+	}
+	var floorColors []color.Color
+	for _, floor := range getSprites().floors {
+		center := floor.Bounds().Min.Add(image.Pt(cellWidth/2, cellHeight/2))
+		floorColors = append(floorColors, floor.At(center.X, center.Y))
+	}
+	for _, from := range floorColors {
+		for _, to := range floorColors {
+			for _, t := range []float64{0.25, 0.5, 0.75} {
+				add(blend(from, to, t))
+			}
+		}
+	}
+	return pal
+}
+
+func blend(c1, c2 color.Color, t float64) color.Color {
+	r1, g1, b1, _ := c1.RGBA()
+	r2, g2, b2, _ := c2.RGBA()
+	mix := func(a, b uint32) uint8 {
+		return uint8((float64(a)*(1-t) + float64(b)*t) / 0x101)
+	}
+	return color.RGBA{mix(r1, r2), mix(g1, g2), mix(b1, b2), 0xff}
+}
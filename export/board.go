@@ -0,0 +1,251 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+// Size of a slot of the circuit board, as in play.CircuitBoardRenderer.
+const (
+	slotWidth  = 32
+	slotHeight = 32
+)
+
+// Colors of the circuit board tiles.
+const (
+	slotColor   = "#706e6e"
+	chipColor   = "#6d6d6d"
+	lineColor   = "#ffffff"
+	activeColor = "#60ec2a"
+	yesColor    = "#228b22"
+	noColor     = "#ff3131"
+)
+
+var floorColors = map[model.Color]string{
+	model.Red:    "#f05555",
+	model.Yellow: "#fddf6c",
+	model.Blue:   "#83e4ff",
+}
+
+type chipShape int
+
+const (
+	pillShape    chipShape = iota // The start chip
+	squareShape                   // Chips that give a command
+	diamondShape                  // Decision chips
+)
+
+type chipStyle struct {
+	shape     chipShape
+	fill      string
+	label     string
+	textColor string
+}
+
+var chipStyles = map[model.ChipType]chipStyle{
+	model.StartChip:         {pillShape, "#000000", "START", lineColor},
+	model.ForwardChip:       {squareShape, chipColor, "FWD", lineColor},
+	model.TurnLeftChip:      {squareShape, chipColor, "LEFT", lineColor},
+	model.TurnRightChip:     {squareShape, chipColor, "RIGHT", lineColor},
+	model.PaintRedChip:      {squareShape, floorColors[model.Red], "PAINT", "#000000"},
+	model.PaintYellowChip:   {squareShape, floorColors[model.Yellow], "PAINT", "#000000"},
+	model.PaintBlueChip:     {squareShape, floorColors[model.Blue], "PAINT", "#000000"},
+	model.IsWallAheadChip:   {diamondShape, chipColor, "WALL?", lineColor},
+	model.IsFloorRedChip:    {diamondShape, floorColors[model.Red], "RED?", "#000000"},
+	model.IsFloorYellowChip: {diamondShape, floorColors[model.Yellow], "YEL?", "#000000"},
+	model.IsFloorBlueChip:   {diamondShape, floorColors[model.Blue], "BLUE?", "#000000"},
+}
+
+// SVGOptions control the drawing made by WriteBoardSVG.
+type SVGOptions struct {
+	// Path is highlighted if not nil.  Otherwise the chips and arrows that
+	// are active on the board are, as in the game.
+	Path *Path
+}
+
+// A Path is a set of chips and arrows of a circuit board, e.g. the ones used
+// during a run.
+type Path struct {
+	chips  map[model.Position]bool
+	arrows map[pathArrow]bool
+}
+
+type pathArrow struct {
+	pos model.Position
+	dir model.Orientation
+}
+
+// NewPath returns an empty path.
+func NewPath() *Path {
+	return &Path{
+		chips:  map[model.Position]bool{},
+		arrows: map[pathArrow]bool{},
+	}
+}
+
+// AddActive adds the chips and arrows that are active on the board to the
+// path.
+func (p *Path) AddActive(b *model.CircuitBoard) {
+	w, h := b.Size()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			chip := b.ChipAt(x, y)
+			if !chip.IsActive() {
+				continue
+			}
+			pos := model.Position{X: x, Y: y}
+			p.chips[pos] = true
+			for o := model.North; o <= model.West; o++ {
+				if chip.IsArrowActive(o) {
+					p.arrows[pathArrow{pos, o}] = true
+				}
+			}
+		}
+	}
+}
+
+// HasChip returns true if the chip at pos is on the path.
+func (p *Path) HasChip(pos model.Position) bool {
+	return p.chips[pos]
+}
+
+// HasArrow returns true if the arrow leaving the chip at pos in direction o
+// is on the path.
+func (p *Path) HasArrow(pos model.Position, o model.Orientation) bool {
+	return p.arrows[pathArrow{pos, o}]
+}
+
+// RunPath runs the board on the level like model.RunLevel and returns the
+// chips and arrows that were used.
+func RunPath(level *model.Level, board *model.CircuitBoard, maxSteps int) (*Path, model.RunResult) {
+	path := NewPath()
+	result := model.RunLevelFunc(level, board, maxSteps, func(c *model.LevelController) {
+		path.AddActive(board)
+	})
+	return path, result
+}
+
+// BoardBounds returns the size of the drawing of a circuit board.
+func BoardBounds(b *model.CircuitBoard) image.Rectangle {
+	w, h := b.Size()
+	return image.Rect(0, 0, w*slotWidth, h*slotHeight)
+}
+
+// WriteBoardSVG draws the circuit board in SVG format, with the same layout
+// as play.CircuitBoardRenderer.DrawCircuitBoard.
+func WriteBoardSVG(w io.Writer, b *model.CircuitBoard, opts SVGOptions) error {
+	bw := bufio.NewWriter(w)
+	width, height := b.Size()
+	bounds := BoardBounds(b)
+	isChipActive := func(pos model.Position) bool {
+		if opts.Path != nil {
+			return opts.Path.HasChip(pos)
+		}
+		return b.ChipAt(pos.X, pos.Y).IsActive()
+	}
+	isArrowActive := func(pos model.Position, o model.Orientation) bool {
+		if opts.Path != nil {
+			return opts.Path.HasArrow(pos, o)
+		}
+		return b.ChipAt(pos.X, pos.Y).IsArrowActive(o)
+	}
+
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %[1]d %[2]d">`+"\n", bounds.Dx(), bounds.Dy())
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="#000000"/>`+"\n", bounds.Dx(), bounds.Dy())
+
+	// Draw the background
+	fmt.Fprintf(bw, `<g fill="none" stroke="%s">`+"\n", slotColor)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			cx, cy := slotCenter(x, y)
+			fmt.Fprintf(bw, `<g transform="translate(%g %g)"><rect x="-5.5" y="-5.5" width="11" height="11"/><path d="M0 -16V-7M0 7V16M-16 0H-7M7 0H16" stroke-width="2"/></g>`+"\n", cx, cy)
+		}
+	}
+	bw.WriteString("</g>\n")
+
+	// Draw the arrows
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			pos := model.Position{X: x, Y: y}
+			chip := b.ChipAt(x, y)
+			if o, ok := chip.ArrowYes(); ok {
+				t := model.NoArrow // Plain arrow
+				if chip.IsTest() {
+					t = model.ArrowYes
+				}
+				writeArrow(bw, pos, o, t, isArrowActive(pos, o))
+			}
+			if o, ok := chip.ArrowNo(); ok && chip.IsTest() {
+				writeArrow(bw, pos, o, model.ArrowNo, isArrowActive(pos, o))
+			}
+		}
+	}
+
+	// Draw the chips
+	bw.WriteString(`<g font-family="sans-serif" font-size="5.5" font-weight="bold" text-anchor="middle" dominant-baseline="central">` + "\n")
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			chip := b.ChipAt(x, y)
+			if chip.Type() != model.NoChip {
+				writeChip(bw, chip.Type(), x, y, isChipActive(model.Position{X: x, Y: y}))
+			}
+		}
+	}
+	bw.WriteString("</g>\n</svg>\n")
+	return bw.Flush()
+}
+
+func slotCenter(x, y int) (float64, float64) {
+	return (float64(x) + 0.5) * slotWidth, (float64(y) + 0.5) * slotHeight
+}
+
+// writeArrow draws an arrow from the center of the slot at pos to the center
+// of the next slot in direction o, marked as a yes or no arrow unless t is
+// NoArrow.  Arrows are drawn pointing north around the middle of the border
+// between the slots, then rotated.
+func writeArrow(w *bufio.Writer, pos model.Position, o model.Orientation, t model.ArrowType, active bool) {
+	v := o.VelocityForward()
+	cx, cy := slotCenter(pos.X, pos.Y)
+	cx += float64(v.Dx) * slotWidth / 2
+	cy += float64(v.Dy) * slotHeight / 2
+	color := lineColor
+	if active {
+		color = activeColor
+	}
+	fmt.Fprintf(w, `<g transform="translate(%g %g) rotate(%d)" fill="none" stroke-width="2">`, cx, cy, 90*int(o))
+	fmt.Fprintf(w, `<path d="M0 16V-16M-4 3L0 -2L4 3" stroke="%s"/>`, color)
+	switch t {
+	case model.ArrowYes:
+		fmt.Fprintf(w, `<path d="M5 1L7 3L11 -2" stroke="%s"/>`, yesColor)
+	case model.ArrowNo:
+		fmt.Fprintf(w, `<path d="M6 -3L11 3M6 3L11 -3" stroke="%s"/>`, noColor)
+	}
+	w.WriteString("</g>\n")
+}
+
+func writeChip(w *bufio.Writer, t model.ChipType, x, y int, active bool) {
+	style := chipStyles[t]
+	cx, cy := slotCenter(x, y)
+	stroke, textColor, strokeWidth := lineColor, style.textColor, 1
+	if active {
+		stroke, strokeWidth = activeColor, 2
+		if textColor == lineColor {
+			// Dark text on colored chips stays dark so it can be read
+			textColor = activeColor
+		}
+	}
+	fmt.Fprintf(w, `<g transform="translate(%g %g)" fill="%s" stroke="%s" stroke-width="%d">`, cx, cy, style.fill, stroke, strokeWidth)
+	switch style.shape {
+	case pillShape:
+		w.WriteString(`<rect x="-12" y="-8" width="24" height="16" rx="8"/>`)
+	case squareShape:
+		w.WriteString(`<rect x="-11.5" y="-11.5" width="23" height="23"/>`)
+	case diamondShape:
+		w.WriteString(`<path d="M0 -12L12 0L0 12L-12 0Z"/>`)
+	}
+	fmt.Fprintf(w, `<text fill="%s" stroke="none">%s</text></g>`+"\n", textColor, style.label)
+}
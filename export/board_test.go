@@ -0,0 +1,91 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+func TestRunPath(t *testing.T) {
+	level, board := testLevel(t)
+	path, result := RunPath(level, board, 100)
+	if result.Outcome != model.Won {
+		t.Fatalf("got %s", result.Outcome)
+	}
+	for _, pos := range []model.Position{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}} {
+		if !path.HasChip(pos) {
+			t.Errorf("chip at %s not on the path", pos)
+		}
+	}
+	if !path.HasArrow(model.Position{X: 0, Y: 0}, model.East) {
+		t.Errorf("start arrow not on the path")
+	}
+	if path.HasArrow(model.Position{X: 0, Y: 0}, model.South) {
+		t.Errorf("missing arrow on the path")
+	}
+}
+
+func TestWriteBoardSVG(t *testing.T) {
+	level, board := testLevel(t)
+	path, _ := RunPath(level, board, 100)
+	decisions, err := model.CircuitBoardFromString(`
+|ST -> W? n> TL|
+|      yv      |
+|      MF      |`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		board      *model.CircuitBoard
+		opts       SVGOptions
+		wantSize   string
+		wantActive int
+		wantYes    int
+		wantNo     int
+	}{
+		{name: "No path", board: board, wantSize: `width="64" height="64"`},
+		{name: "Path", board: board, opts: SVGOptions{Path: path}, wantSize: `width="64" height="64"`, wantActive: 4 + 2},
+		{name: "Decisions", board: decisions, wantSize: `width="96" height="64"`, wantYes: 1, wantNo: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := WriteBoardSVG(&b, tt.board, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			svg := b.String()
+			checkXML(t, svg)
+			if !strings.Contains(svg, tt.wantSize) {
+				t.Errorf("wrong size, want %s:\n%s", tt.wantSize, svg)
+			}
+			// Active arrows and chips have a green outline
+			if got := strings.Count(svg, `stroke="`+activeColor+`"`); got != tt.wantActive {
+				t.Errorf("got %d active items, want %d", got, tt.wantActive)
+			}
+			if got := strings.Count(svg, `stroke="`+yesColor+`"`); got != tt.wantYes {
+				t.Errorf("got %d yes arrows, want %d", got, tt.wantYes)
+			}
+			if got := strings.Count(svg, `stroke="`+noColor+`"`); got != tt.wantNo {
+				t.Errorf("got %d no arrows, want %d", got, tt.wantNo)
+			}
+		})
+	}
+}
+
+func checkXML(t *testing.T, s string) {
+	d := xml.NewDecoder(strings.NewReader(s))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("invalid XML: %s\n%s", err, s)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+// WriteBoardDOT writes the control flow of the circuit board as a graph in
+// the DOT language of Graphviz, i.e. as a flowchart.  Chips are nodes and
+// arrows are edges, going straight through empty slots.  Chips that cannot be
+// reached from the start chip are greyed out and branches that do not lead to
+// a chip end in a red dot.
+func WriteBoardDOT(w io.Writer, b *model.CircuitBoard) error {
+	bw := bufio.NewWriter(w)
+	flow := model.NewBoardFlow(b)
+	bw.WriteString("digraph board {\n")
+	bw.WriteString("\tnode [shape=box, fontname=\"sans-serif\"];\n")
+	bw.WriteString("\tedge [fontname=\"sans-serif\"];\n")
+	for _, c := range flow.Chips {
+		attrs := fmt.Sprintf("label=%q, shape=%s", fmt.Sprintf("%s\n%s", c.Type, c.Pos), dotShape(c.Type))
+		if !c.Reachable {
+			attrs += `, style=dashed, color=grey, fontcolor=grey, tooltip="unreachable"`
+		}
+		fmt.Fprintf(bw, "\t%s [%s];\n", dotNodeID(c.Pos), attrs)
+	}
+	for _, c := range flow.Chips {
+		for _, br := range c.Branches {
+			var label string
+			if c.Type.IsDecision() {
+				label = br.Arrow.String()
+			}
+			if !br.Dangling() {
+				attrs := dotEdgeColor(br.Arrow, c)
+				if label != "" {
+					attrs += fmt.Sprintf(", label=%q", label)
+				}
+				fmt.Fprintf(bw, "\t%s -> %s [%s];\n", dotNodeID(c.Pos), dotNodeID(br.To), attrs)
+				continue
+			}
+			if label != "" {
+				label += ": "
+			}
+			label += br.End.String()
+			end := fmt.Sprintf("%s_%s", dotNodeID(c.Pos), br.Arrow)
+			fmt.Fprintf(bw, "\t%s [shape=point, color=red];\n", end)
+			fmt.Fprintf(bw, "\t%s -> %s [color=red, fontcolor=red, style=dashed, label=%q];\n", dotNodeID(c.Pos), end, label)
+		}
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+func dotNodeID(pos model.Position) string {
+	return fmt.Sprintf("c%d_%d", pos.X, pos.Y)
+}
+
+func dotShape(t model.ChipType) string {
+	switch {
+	case t == model.StartChip:
+		return "oval"
+	case t.IsDecision():
+		return "diamond"
+	default:
+		return "box"
+	}
+}
+
+func dotEdgeColor(a model.ArrowType, from *model.FlowChip) string {
+	switch {
+	case !from.Reachable:
+		return "color=grey, fontcolor=grey"
+	case !from.Type.IsDecision():
+		return "color=black"
+	case a == model.ArrowYes:
+		return `color="` + yesColor + `"`
+	default:
+		return `color="` + noColor + `"`
+	}
+}
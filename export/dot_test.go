@@ -0,0 +1,69 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+func TestWriteBoardDOT(t *testing.T) {
+	tests := []struct {
+		name  string
+		board string
+		want  string
+	}{
+		{
+			name:  "Forward loop",
+			board: forwardLoopBoard,
+			want: `digraph board {
+	node [shape=box, fontname="sans-serif"];
+	edge [fontname="sans-serif"];
+	c0_0 [label="start\n(0, 0)", shape=oval];
+	c1_0 [label="move forward\n(1, 0)", shape=box];
+	c0_0 -> c1_0 [color=black];
+	c1_0 -> c0_0 [color=black];
+}
+`,
+		},
+		{
+			name: "Unreachable and dangling",
+			board: `
+|ST -> W? n> TL|
+|      yv      |
+|TR    ..      |`,
+			want: `digraph board {
+	node [shape=box, fontname="sans-serif"];
+	edge [fontname="sans-serif"];
+	c0_0 [label="start\n(0, 0)", shape=oval];
+	c1_0 [label="wall ahead?\n(1, 0)", shape=diamond];
+	c2_0 [label="turn left\n(2, 0)", shape=box];
+	c0_1 [label="turn right\n(0, 1)", shape=box, style=dashed, color=grey, fontcolor=grey, tooltip="unreachable"];
+	c0_0 -> c1_0 [color=black];
+	c1_0_yes [shape=point, color=red];
+	c1_0 -> c1_0_yes [color=red, fontcolor=red, style=dashed, label="yes: empty slot"];
+	c1_0 -> c2_0 [color="#ff3131", label="no"];
+	c2_0_yes [shape=point, color=red];
+	c2_0 -> c2_0_yes [color=red, fontcolor=red, style=dashed, label="missing arrow"];
+	c0_1_yes [shape=point, color=red];
+	c0_1 -> c0_1_yes [color=red, fontcolor=red, style=dashed, label="missing arrow"];
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board, err := model.CircuitBoardFromString(tt.board)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b bytes.Buffer
+			if err := WriteBoardDOT(&b, board); err != nil {
+				t.Fatal(err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
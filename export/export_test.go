@@ -0,0 +1,83 @@
+package export
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+func TestMain(m *testing.M) {
+	// model.RunLevel logs every step
+	log.SetOutput(ioutil.Discard)
+	os.Exit(m.Run())
+}
+
+const straightLevel = `
++--+--+--+--+--+--+--+--+
+|R> R  R  RF R  R  R  RF|
++--+--+--+--+--+--+--+--+`
+
+const forwardLoopBoard = `
+|ST -> MF|
+| ^     v|
+|.. <- ..|`
+
+func testLevel(t *testing.T) (*model.Level, *model.CircuitBoard) {
+	level, err := model.LevelFromString("straight", straightLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	board, err := model.CircuitBoardFromString(forwardLoopBoard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return level, board
+}
+
+func TestWriteMazePNG(t *testing.T) {
+	level, _ := testLevel(t)
+	var b bytes.Buffer
+	if err := WriteMazePNG(&b, level.Maze); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := image.Rect(0, 0, 8*cellWidth+2*margin, cellHeight+2*margin)
+	if got := img.Bounds(); got != want {
+		t.Errorf("got bounds %v, want %v", got, want)
+	}
+	// The margin is background and the maze is drawn over it
+	if r, g, b, _ := img.At(0, 0).RGBA(); r != 0 || g != 0 || b != 0 {
+		t.Errorf("corner of the image is not background")
+	}
+	if img.At(margin+cellWidth+cellWidth/2, margin+cellHeight/2) == img.At(0, 0) {
+		t.Errorf("floor not drawn")
+	}
+}
+
+func TestRunGIF(t *testing.T) {
+	level, board := testLevel(t)
+	anim, result := RunGIF(level, board, GIFOptions{FramesPerStep: 2})
+	if result.Outcome != model.Won || result.Steps != 7 {
+		t.Fatalf("got %+v", result)
+	}
+	// One frame at the start, two per step and one at the end
+	if got, want := len(anim.Image), 1+7*2+1; got != want {
+		t.Errorf("got %d frames, want %d", got, want)
+	}
+	if len(anim.Delay) != len(anim.Image) {
+		t.Errorf("got %d delays for %d frames", len(anim.Delay), len(anim.Image))
+	}
+	var b bytes.Buffer
+	if _, err := WriteRunGIF(&b, level, board, GIFOptions{}); err != nil {
+		t.Fatal(err)
+	}
+}
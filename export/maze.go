@@ -0,0 +1,215 @@
+// Package export draws mazes and circuit boards without ebiten, so that
+// pictures of levels and runs can be made on machines without a display.
+package export
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/arnodel/gobot2flags/model"
+	"github.com/arnodel/gobot2flags/resources/images"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+)
+
+const (
+	cellWidth  = images.FrameWidth
+	cellHeight = images.FrameHeight
+	wallWidth  = images.WallWidth
+	wallHeight = images.WallHeight
+
+	// Space around the maze, as in play.MazeRenderer.MazeBounds
+	margin = 16
+)
+
+// Background is the color behind the maze, black as in the game.
+var Background color.Color = color.Black
+
+// A sheet is a sprite sheet made of frames of the same size.
+type sheet struct {
+	img              image.Image
+	width, height    int
+	anchorX, anchorY float64
+}
+
+func (s sheet) frame(variant, frame int) image.Image {
+	b := s.img.Bounds()
+	variant %= b.Dy() / s.height
+	frame %= b.Dx() / s.width
+	r := image.Rect(frame*s.width, variant*s.height, (frame+1)*s.width, (variant+1)*s.height)
+	return s.img.(subImager).SubImage(r.Add(b.Min))
+}
+
+type subImager interface {
+	SubImage(r image.Rectangle) image.Image
+}
+
+// The sprites are the same as in the sprites package.
+type mazeSprites struct {
+	floors                       [4]image.Image
+	horizontal, vertical, corner image.Image
+	flag, robot                  sheet
+}
+
+var (
+	spritesOnce sync.Once
+	sprites     mazeSprites
+)
+
+func getSprites() *mazeSprites {
+	spritesOnce.Do(func() {
+		walls := images.Get("greywalls.png").(subImager)
+		floors := sheet{img: images.Get("floors.png"), width: cellWidth, height: cellHeight}
+		sprites = mazeSprites{
+			horizontal: walls.SubImage(image.Rect(0, cellHeight, cellWidth, cellHeight+wallHeight)),
+			vertical:   walls.SubImage(image.Rect(0, 2*cellHeight, wallWidth, 3*cellHeight)),
+			corner:     walls.SubImage(image.Rect(0, 0, wallWidth, wallHeight)),
+			flag:       sheet{img: images.Get("greenflag.png"), width: cellWidth, height: cellHeight, anchorX: 10, anchorY: 28},
+			robot:      sheet{img: images.Get("robot.png"), width: cellWidth, height: cellHeight, anchorX: cellWidth / 2, anchorY: cellHeight / 2},
+		}
+		for i := range sprites.floors {
+			sprites.floors[i] = floors.frame(0, i)
+		}
+	})
+	return &sprites
+}
+
+// MazeBounds returns the size of the image of a maze.
+func MazeBounds(m *model.Maze) image.Rectangle {
+	w, h := m.Size()
+	return image.Rect(0, 0, cellWidth*w+2*margin, cellHeight*h+2*margin)
+}
+
+// MazeImage draws the maze as the game does.  The robot is shown at time t,
+// between 0 and 1, of the command it is carrying out and animated sprites
+// (i.e. flags) show the given frame.
+func MazeImage(m *model.Maze, t float64, frame int) *image.RGBA {
+	img := image.NewRGBA(MazeBounds(m))
+	DrawMaze(img, m, t, frame)
+	return img
+}
+
+// WriteMazePNG writes a picture of the maze in its current state in PNG
+// format.
+func WriteMazePNG(w io.Writer, m *model.Maze) error {
+	return png.Encode(w, MazeImage(m, 0, 0))
+}
+
+// DrawMaze draws the maze on dst, like MazeImage.
+func DrawMaze(dst draw.Image, m *model.Maze, t float64, frame int) {
+	s := getSprites()
+	w, h := m.Size()
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(Background), image.Point{}, draw.Src)
+
+	// Draw the floors first as they are under everything
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			drawAt(dst, s.floors[m.CellAt(x, y).Color()], x*cellWidth, y*cellHeight)
+		}
+	}
+
+	// Then the other things from back to front, as play.MazeRenderer does
+	// with an engine.ImageStack
+	var stack drawStack
+	for y := 0; y <= h; y++ {
+		for x := 0; x <= w; x++ {
+			x, y := x, y
+			cell := m.CellAt(x, y)
+			if cell.CornerWall() {
+				stack.add(float64(y*cellHeight)-1e-3, func() {
+					drawAt(dst, s.corner, x*cellWidth-wallWidth/2, y*cellHeight-wallHeight)
+				})
+			}
+			if y < h && cell.WestWall() {
+				stack.add(float64((y+1)*cellHeight), func() {
+					drawAt(dst, s.vertical, x*cellWidth-wallWidth/2, y*cellHeight)
+				})
+			}
+			if x < w && cell.NorthWall() {
+				stack.add(float64(y*cellHeight), func() {
+					drawAt(dst, s.horizontal, x*cellWidth, y*cellHeight-wallHeight)
+				})
+			}
+			if x < w && y < h && cell.Flag() {
+				variant := 0
+				if cell.Captured() {
+					variant = 1
+				}
+				flagY := y*cellHeight + 9
+				stack.add(float64(flagY), func() {
+					drawAt(dst, s.flag.frame(variant, frame), x*cellWidth+6-int(s.flag.anchorX), flagY-int(s.flag.anchorY))
+				})
+			}
+		}
+	}
+	if robot := m.Robot(); robot != nil {
+		rx, ry := robot.CoordsAt(t)
+		robotY := (ry + 0.5) * cellHeight
+		stack.add(robotY, func() {
+			drawRotated(dst, s.robot, robot.AngleAt(t), (rx+0.5)*cellWidth, robotY)
+		})
+		if col := robot.ColorPainting(); col != model.NoColor {
+			stack.add(robotY, func() {
+				drawFaded(dst, s.floors[col], robot.X*cellWidth, robot.Y*cellHeight, t)
+			})
+		}
+	}
+	stack.draw()
+}
+
+type drawStack []stackItem
+
+type stackItem struct {
+	z    float64
+	draw func()
+}
+
+func (s *drawStack) add(z float64, f func()) {
+	*s = append(*s, stackItem{z, f})
+}
+
+func (s drawStack) draw() {
+	sort.SliceStable(s, func(i, j int) bool {
+		return s[i].z < s[j].z
+	})
+	for _, item := range s {
+		item.draw()
+	}
+}
+
+// drawAt draws src with its top left corner at (x, y) in maze coordinates.
+func drawAt(dst draw.Image, src image.Image, x, y int) {
+	b := src.Bounds()
+	r := b.Sub(b.Min).Add(image.Pt(x+margin, y+margin))
+	draw.Draw(dst, r, src, b.Min, draw.Over)
+}
+
+// drawFaded draws src like drawAt, with opacity alpha.
+func drawFaded(dst draw.Image, src image.Image, x, y int, alpha float64) {
+	b := src.Bounds()
+	r := b.Sub(b.Min).Add(image.Pt(x+margin, y+margin))
+	mask := image.NewUniform(color.Alpha{uint8(math.Round(alpha * 0xff))})
+	draw.DrawMask(dst, r, src, b.Min, mask, image.Point{}, draw.Over)
+}
+
+// drawRotated draws a sprite rotated by angle around its anchor, with the
+// anchor at (x, y) in maze coordinates.  Nearest neighbour interpolation keeps
+// the colors of the sprite, which matters for GIF palettes.
+func drawRotated(dst draw.Image, s sheet, angle, x, y float64) {
+	src := s.frame(0, 0)
+	b := src.Bounds()
+	sin, cos := math.Sincos(angle)
+	ax, ay := float64(b.Min.X)+s.anchorX, float64(b.Min.Y)+s.anchorY
+	x, y = x+margin, y+margin
+	m := f64.Aff3{
+		cos, -sin, x - (cos*ax - sin*ay),
+		sin, cos, y - (sin*ax + cos*ay),
+	}
+	xdraw.NearestNeighbor.Transform(dst, m, src, b, xdraw.Over, nil)
+}
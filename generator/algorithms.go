@@ -0,0 +1,143 @@
+package generator
+
+// carveBacktracker walks from a random cell to random unvisited neighbours,
+// knocking down walls on the way, and backtracks when stuck.
+func (g *grid) carveBacktracker() {
+	visited := make([]bool, g.size())
+	start := g.randomCell()
+	visited[start] = true
+	stack := []int{start}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		var choices []wall
+		for _, o := range orientations {
+			if j, ok := g.neighbour(i, o); ok && !visited[j] {
+				choices = append(choices, wall{i, o})
+			}
+		}
+		if len(choices) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		w := choices[g.rand.Intn(len(choices))]
+		j, _ := g.neighbour(w.cell, w.side)
+		g.removeWall(w.cell, w.side)
+		visited[j] = true
+		stack = append(stack, j)
+	}
+}
+
+// carvePrim grows the maze from a random cell, each time knocking down a
+// random wall between the maze and a cell not yet in it.
+func (g *grid) carvePrim() {
+	inMaze := make([]bool, g.size())
+	var frontier []wall
+	add := func(i int) {
+		inMaze[i] = true
+		for _, o := range orientations {
+			if j, ok := g.neighbour(i, o); ok && !inMaze[j] {
+				frontier = append(frontier, wall{i, o})
+			}
+		}
+	}
+	add(g.randomCell())
+	for len(frontier) > 0 {
+		k := g.rand.Intn(len(frontier))
+		w := frontier[k]
+		frontier[k] = frontier[len(frontier)-1]
+		frontier = frontier[:len(frontier)-1]
+		if j, _ := g.neighbour(w.cell, w.side); !inMaze[j] {
+			g.removeWall(w.cell, w.side)
+			add(j)
+		}
+	}
+}
+
+// carveRooms clears a few rectangular rooms, then joins the rooms and the
+// remaining cells with corridors by knocking down random walls between parts
+// of the maze that are not connected yet (Kruskal's algorithm).
+func (g *grid) carveRooms() {
+	parts := newPartition(g.size())
+	taken := make([]bool, g.size())
+	for attempt := 0; attempt < g.size()/4; attempt++ {
+		w := 2 + g.rand.Intn(3)
+		h := 2 + g.rand.Intn(3)
+		if w > g.width || h > g.height {
+			continue
+		}
+		x0 := g.rand.Intn(g.width - w + 1)
+		y0 := g.rand.Intn(g.height - h + 1)
+		if g.overlaps(taken, x0, y0, w, h) {
+			continue
+		}
+		for y := y0; y < y0+h; y++ {
+			for x := x0; x < x0+w; x++ {
+				i := g.wrap(x, y)
+				taken[i] = true
+				if x > x0 {
+					g.west[i] = false
+					parts.join(i, g.wrap(x-1, y))
+				}
+				if y > y0 {
+					g.north[i] = false
+					parts.join(i, g.wrap(x, y-1))
+				}
+			}
+		}
+	}
+	walls := g.innerWalls()
+	g.rand.Shuffle(len(walls), func(i, j int) {
+		walls[i], walls[j] = walls[j], walls[i]
+	})
+	for _, w := range walls {
+		j, _ := g.neighbour(w.cell, w.side)
+		if parts.join(w.cell, j) {
+			g.removeWall(w.cell, w.side)
+		}
+	}
+}
+
+// overlaps returns true if a room would overlap or touch a room already
+// taken, so that rooms stay apart.
+func (g *grid) overlaps(taken []bool, x0, y0, w, h int) bool {
+	for y := y0 - 1; y <= y0+h; y++ {
+		for x := x0 - 1; x <= x0+w; x++ {
+			if x < 0 || x >= g.width || y < 0 || y >= g.height {
+				continue
+			}
+			if taken[g.wrap(x, y)] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// A partition keeps track of which cells are connected (union-find).
+type partition []int
+
+func newPartition(n int) partition {
+	p := make(partition, n)
+	for i := range p {
+		p[i] = i
+	}
+	return p
+}
+
+func (p partition) find(i int) int {
+	for p[i] != i {
+		p[i] = p[p[i]]
+		i = p[i]
+	}
+	return i
+}
+
+// join merges the parts of i and j and returns true if they were different.
+func (p partition) join(i, j int) bool {
+	i, j = p.find(i), p.find(j)
+	if i == j {
+		return false
+	}
+	p[i] = j
+	return true
+}
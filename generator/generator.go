@@ -0,0 +1,164 @@
+// Package generator builds random levels.
+//
+// A level is generated by carving a perfect maze (where there is exactly one
+// way between any two cells) out of a grid with all the walls up, using one
+// of several classic algorithms.  Some walls can then be knocked down to make
+// loops.  Finally the floor is painted, and the robot and the flags are put
+// in cells that the robot can get to.  The same options, including the
+// seed, always give the same level.
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+// Algorithm is the way the maze is carved.
+type Algorithm int
+
+const (
+	RecursiveBacktracker Algorithm = iota // Long winding corridors
+	Prim                                  // Many short dead ends
+	RoomsAndCorridors                     // Open rooms joined by corridors
+)
+
+var algorithmNames = map[Algorithm]string{
+	RecursiveBacktracker: "backtracker",
+	Prim:                 "prim",
+	RoomsAndCorridors:    "rooms",
+}
+
+func (a Algorithm) String() string {
+	if name, ok := algorithmNames[a]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// AlgorithmByName returns the algorithm whose String() is name.
+func AlgorithmByName(name string) (Algorithm, bool) {
+	for a, n := range algorithmNames {
+		if n == name {
+			return a, true
+		}
+	}
+	return 0, false
+}
+
+// ColorLayout is the way the floor is painted.
+type ColorLayout int
+
+const (
+	SingleColor  ColorLayout = iota // All cells red
+	RandomColors                    // Each cell a random color
+	ColorPatches                    // Patches of cells with the same color
+)
+
+var colorLayoutNames = map[ColorLayout]string{
+	SingleColor:  "single",
+	RandomColors: "random",
+	ColorPatches: "patches",
+}
+
+func (c ColorLayout) String() string {
+	if name, ok := colorLayoutNames[c]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ColorLayoutByName returns the color layout whose String() is name.
+func ColorLayoutByName(name string) (ColorLayout, bool) {
+	for c, n := range colorLayoutNames {
+		if n == name {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+// Options describe the level to generate.
+type Options struct {
+	Seed          int64
+	Name          string
+	Width, Height int
+	Algorithm     Algorithm
+	Colors        ColorLayout
+	Flags         int
+
+	// WallDensity is the fraction of the walls of the perfect maze that are
+	// kept, between 0 (no walls inside the maze) and 1 (the perfect maze).
+	WallDensity float64
+
+	// When Toroidal is true, corridors can go across the edges of the maze,
+	// which wraps around as usual.  Otherwise the maze is surrounded by
+	// walls.
+	Toroidal bool
+}
+
+// Generate returns a new level.  All the flags in the level can be reached by
+// the robot.
+func Generate(opts Options) (*model.Level, error) {
+	if opts.Width < 1 || opts.Height < 1 {
+		return nil, fmt.Errorf("invalid maze size %dx%d", opts.Width, opts.Height)
+	}
+	if opts.WallDensity < 0 || opts.WallDensity > 1 {
+		return nil, fmt.Errorf("wall density %g not between 0 and 1", opts.WallDensity)
+	}
+	if opts.Flags < 1 {
+		return nil, errors.New("there must be at least one flag")
+	}
+	if opts.Flags >= opts.Width*opts.Height {
+		return nil, fmt.Errorf("too many flags for a %dx%d maze", opts.Width, opts.Height)
+	}
+	g := newGrid(opts.Width, opts.Height, opts.Toroidal, rand.New(rand.NewSource(opts.Seed)))
+	switch opts.Algorithm {
+	case RecursiveBacktracker:
+		g.carveBacktracker()
+	case Prim:
+		g.carvePrim()
+	case RoomsAndCorridors:
+		g.carveRooms()
+	default:
+		return nil, fmt.Errorf("unknown algorithm %d", opts.Algorithm)
+	}
+	g.knockDownWalls(1 - opts.WallDensity)
+	switch opts.Colors {
+	case SingleColor:
+		g.paintSingle(model.Red)
+	case RandomColors:
+		g.paintRandom()
+	case ColorPatches:
+		g.paintPatches()
+	default:
+		return nil, fmt.Errorf("unknown color layout %d", opts.Colors)
+	}
+
+	maze := g.maze()
+	robot := g.randomCell()
+	maze.SetRobot(g.pos(robot), model.Orientation(g.rand.Intn(4)))
+
+	// All cells are connected, but check anyway so a bug in the algorithms
+	// cannot produce an impossible level.
+	reachable := model.AnalyzeMaze(maze).Reachable
+	g.rand.Shuffle(len(reachable), func(i, j int) {
+		reachable[i], reachable[j] = reachable[j], reachable[i]
+	})
+	flags := 0
+	for _, pos := range reachable {
+		if flags == opts.Flags {
+			break
+		}
+		if pos != g.pos(robot) {
+			maze.UpdateCellAt(pos.X, pos.Y, model.FF)
+			flags++
+		}
+	}
+	if flags < opts.Flags {
+		return nil, fmt.Errorf("only %d cells for %d flags", flags, opts.Flags)
+	}
+	return model.NewLevel(opts.Name, maze), nil
+}
@@ -0,0 +1,98 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+func TestGenerate(t *testing.T) {
+	for _, algo := range []Algorithm{RecursiveBacktracker, Prim, RoomsAndCorridors} {
+		for _, colors := range []ColorLayout{SingleColor, RandomColors, ColorPatches} {
+			for _, toroidal := range []bool{false, true} {
+				opts := Options{
+					Seed:        42,
+					Width:       9,
+					Height:      7,
+					Algorithm:   algo,
+					Colors:      colors,
+					Flags:       4,
+					WallDensity: 0.8,
+					Toroidal:    toroidal,
+				}
+				name := algo.String() + "/" + colors.String() + "/bounded"
+				if toroidal {
+					name = algo.String() + "/" + colors.String() + "/toroidal"
+				}
+				t.Run(name, func(t *testing.T) {
+					checkGenerate(t, opts)
+				})
+			}
+		}
+	}
+}
+
+func checkGenerate(t *testing.T, opts Options) {
+	l, err := Generate(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := model.AnalyzeMaze(l.Maze)
+	if len(a.SealedFlags) != 0 || len(a.ReachableFlags) != opts.Flags {
+		t.Errorf("%d flags reachable and %d sealed, want %d reachable", len(a.ReachableFlags), len(a.SealedFlags), opts.Flags)
+	}
+	if a.MinMoves < 0 {
+		t.Errorf("min moves unknown")
+	}
+	if !opts.Toroidal {
+		for x := 0; x < opts.Width; x++ {
+			if !l.Maze.HasWallAt(x, 0, model.North) {
+				t.Errorf("no wall north of (%d, 0)", x)
+			}
+		}
+		for y := 0; y < opts.Height; y++ {
+			if !l.Maze.HasWallAt(0, y, model.West) {
+				t.Errorf("no wall west of (0, %d)", y)
+			}
+		}
+	}
+
+	// The level can be written to .r2f and read back
+	s := model.LevelToString(l, "")
+	l2, err := model.LevelFromString("", s)
+	if err != nil {
+		t.Fatalf("cannot read back level: %s\n%s", err, s)
+	}
+	if s2 := model.LevelToString(l2, ""); s2 != s {
+		t.Errorf("level changed when read back:\n%s\n%s", s, s2)
+	}
+
+	// Generating again gives the same level
+	l3, err := Generate(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s3 := model.LevelToString(l3, ""); s3 != s {
+		t.Errorf("different levels with the same seed:\n%s\n%s", s, s3)
+	}
+}
+
+func TestGenerate_errors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"Empty maze", Options{Width: 0, Height: 3, Flags: 1}},
+		{"No flags", Options{Width: 3, Height: 3}},
+		{"Too many flags", Options{Width: 2, Height: 2, Flags: 4}},
+		{"Bad wall density", Options{Width: 3, Height: 3, Flags: 1, WallDensity: 2}},
+		{"Bad algorithm", Options{Width: 3, Height: 3, Flags: 1, Algorithm: -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Generate(tt.opts); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package generator
+
+import (
+	"math/rand"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+// A grid is a maze being generated.  As in model.Maze, each cell holds its
+// north and west walls; the south and east walls belong to the neighbouring
+// cells.
+type grid struct {
+	width, height int
+	toroidal      bool
+	north, west   []bool
+	colors        []model.Color
+	rand          *rand.Rand
+}
+
+var orientations = [...]model.Orientation{model.North, model.East, model.South, model.West}
+
+// newGrid returns a grid with all the walls up.
+func newGrid(width, height int, toroidal bool, r *rand.Rand) *grid {
+	n := width * height
+	g := &grid{
+		width:    width,
+		height:   height,
+		toroidal: toroidal,
+		north:    make([]bool, n),
+		west:     make([]bool, n),
+		colors:   make([]model.Color, n),
+		rand:     r,
+	}
+	for i := 0; i < n; i++ {
+		g.north[i] = true
+		g.west[i] = true
+	}
+	return g
+}
+
+func (g *grid) size() int {
+	return g.width * g.height
+}
+
+func (g *grid) pos(i int) model.Position {
+	return model.Position{X: i % g.width, Y: i / g.width}
+}
+
+func (g *grid) randomCell() int {
+	return g.rand.Intn(g.size())
+}
+
+// wrap returns the index of the cell at (x, y), wrapping around the edges.
+func (g *grid) wrap(x, y int) int {
+	x = (x%g.width + g.width) % g.width
+	y = (y%g.height + g.height) % g.height
+	return x + y*g.width
+}
+
+// neighbour returns the cell next to cell i in direction o, if corridors can
+// go there.
+func (g *grid) neighbour(i int, o model.Orientation) (int, bool) {
+	v := o.VelocityForward()
+	p := g.pos(i)
+	x, y := p.X+v.Dx, p.Y+v.Dy
+	if !g.toroidal && (x < 0 || x >= g.width || y < 0 || y >= g.height) {
+		return 0, false
+	}
+	j := g.wrap(x, y)
+	return j, j != i
+}
+
+func (g *grid) hasWall(i int, o model.Orientation) bool {
+	p := g.pos(i)
+	switch o {
+	case model.North:
+		return g.north[i]
+	case model.West:
+		return g.west[i]
+	case model.South:
+		return g.north[g.wrap(p.X, p.Y+1)]
+	default:
+		return g.west[g.wrap(p.X+1, p.Y)]
+	}
+}
+
+func (g *grid) removeWall(i int, o model.Orientation) {
+	p := g.pos(i)
+	switch o {
+	case model.North:
+		g.north[i] = false
+	case model.West:
+		g.west[i] = false
+	case model.South:
+		g.north[g.wrap(p.X, p.Y+1)] = false
+	default:
+		g.west[g.wrap(p.X+1, p.Y)] = false
+	}
+}
+
+// A wall is identified by a cell and the side of the cell it is on.
+type wall struct {
+	cell int
+	side model.Orientation
+}
+
+// innerWalls returns the walls still up that could be knocked down, in a
+// fixed order.
+func (g *grid) innerWalls() []wall {
+	var walls []wall
+	for i := 0; i < g.size(); i++ {
+		for _, o := range [...]model.Orientation{model.North, model.West} {
+			if _, ok := g.neighbour(i, o); ok && g.hasWall(i, o) {
+				walls = append(walls, wall{i, o})
+			}
+		}
+	}
+	return walls
+}
+
+// knockDownWalls removes each inner wall with probability p.
+func (g *grid) knockDownWalls(p float64) {
+	for _, w := range g.innerWalls() {
+		if g.rand.Float64() < p {
+			g.removeWall(w.cell, w.side)
+		}
+	}
+}
+
+func (g *grid) paintSingle(col model.Color) {
+	for i := range g.colors {
+		g.colors[i] = col
+	}
+}
+
+var paints = [...]model.Color{model.Red, model.Yellow, model.Blue}
+
+func (g *grid) paintRandom() {
+	for i := range g.colors {
+		g.colors[i] = paints[g.rand.Intn(len(paints))]
+	}
+}
+
+// paintPatches picks a few cells at random and paints each cell with the
+// color of the closest one.
+func (g *grid) paintPatches() {
+	n := g.size()/8 + 2
+	seeds := make([]int, n)
+	seedColors := make([]model.Color, n)
+	for k := range seeds {
+		seeds[k] = g.randomCell()
+		seedColors[k] = paints[g.rand.Intn(len(paints))]
+	}
+	for i := range g.colors {
+		best := -1
+		for k, s := range seeds {
+			if d := g.distance(i, s); best == -1 || d < g.distance(i, seeds[best]) {
+				best = k
+			}
+		}
+		g.colors[i] = seedColors[best]
+	}
+}
+
+// distance returns the number of steps between two cells, ignoring walls.
+func (g *grid) distance(i, j int) int {
+	p, q := g.pos(i), g.pos(j)
+	dx, dy := abs(p.X-q.X), abs(p.Y-q.Y)
+	if g.toroidal {
+		dx = min(dx, g.width-dx)
+		dy = min(dy, g.height-dy)
+	}
+	return dx + dy
+}
+
+// maze returns a model.Maze with the walls and colors of the grid, and '+'
+// corners where walls meet.
+func (g *grid) maze() *model.Maze {
+	m := model.NewMaze(g.width, g.height)
+	for i := 0; i < g.size(); i++ {
+		p := g.pos(i)
+		var cell model.Cell
+		if g.north[i] {
+			cell |= model.TF
+		}
+		if g.west[i] {
+			cell |= model.LF
+		}
+		if g.north[i] || g.west[i] || g.north[g.wrap(p.X-1, p.Y)] || g.west[g.wrap(p.X, p.Y-1)] {
+			cell |= model.CF
+		}
+		m.UpdateCellAt(p.X, p.Y, cell|g.colors[i].ToCell())
+	}
+	return m
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,185 @@
+// Package lang is a small programming language for the robot.  Programs can
+// be decompiled from circuit boards, to read a board as code, and compiled
+// onto circuit boards, to write a board as code.
+//
+// A program is a list of statements:
+//
+//	forward, left, right       move the robot
+//	paint red|yellow|blue      paint the floor
+//	stop                       end the run
+//	if COND { ... } else { ... }
+//	while COND { ... }
+//	loop { ... }               repeat forever
+//	name:                      a label...
+//	goto name                  ...and a jump to it
+//
+// where COND is "wall" (there is a wall ahead of the robot) or "floor red",
+// "floor yellow" or "floor blue", optionally preceded by "not".  The run also
+// ends after the last statement.  Comments start with '#'.
+package lang
+
+import (
+	"strings"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+// A Program is a list of statements.
+type Program struct {
+	Body []Stmt
+}
+
+// Stmt is one of *Command, *Stop, *If, *While, *Loop, *Label and *Goto.
+type Stmt interface {
+	write(w *writer)
+}
+
+// A Command is a statement that gives a command to the robot.
+type Command struct {
+	Com model.Command
+}
+
+// Stop ends the run.
+type Stop struct{}
+
+// If runs Then if Cond is true and Else otherwise.
+type If struct {
+	Cond Cond
+	Then []Stmt
+	Else []Stmt
+}
+
+// While runs Body for as long as Cond is true.
+type While struct {
+	Cond Cond
+	Body []Stmt
+}
+
+// Loop runs Body forever.
+type Loop struct {
+	Body []Stmt
+}
+
+// A Label marks the place a Goto jumps to.
+type Label struct {
+	Name string
+
+	node int // Used by Decompile
+}
+
+// Goto jumps to the label with the same name.
+type Goto struct {
+	Label string
+
+	node int // Used by Decompile
+}
+
+// Cond is a condition tested by a decision chip, or its opposite if Not is
+// true.
+type Cond struct {
+	Test model.ChipType // A decision chip type
+	Not  bool
+}
+
+var commandNames = map[model.Command]string{
+	model.MoveForward: "forward",
+	model.TurnLeft:    "left",
+	model.TurnRight:   "right",
+	model.PaintRed:    "paint red",
+	model.PaintYellow: "paint yellow",
+	model.PaintBlue:   "paint blue",
+}
+
+var condNames = map[model.ChipType]string{
+	model.IsWallAheadChip:   "wall",
+	model.IsFloorRedChip:    "floor red",
+	model.IsFloorYellowChip: "floor yellow",
+	model.IsFloorBlueChip:   "floor blue",
+}
+
+func (c Cond) String() string {
+	if c.Not {
+		return "not " + condNames[c.Test]
+	}
+	return condNames[c.Test]
+}
+
+// String returns the program as text, with 4 spaces of indentation for each
+// block.
+func (p *Program) String() string {
+	w := &writer{}
+	w.block(p.Body)
+	return w.String()
+}
+
+type writer struct {
+	strings.Builder
+	indent int
+}
+
+func (w *writer) line(s string) {
+	w.WriteString(strings.Repeat("    ", w.indent))
+	w.WriteString(s)
+	w.WriteByte('\n')
+}
+
+func (w *writer) block(stmts []Stmt) {
+	for _, s := range stmts {
+		s.write(w)
+	}
+}
+
+func (w *writer) nested(head string, stmts []Stmt) {
+	w.line(head + " {")
+	w.indent++
+	w.block(stmts)
+	w.indent--
+}
+
+func (s *Command) write(w *writer) {
+	w.line(commandNames[s.Com])
+}
+
+func (s *Stop) write(w *writer) {
+	w.line("stop")
+}
+
+func (s *If) write(w *writer) {
+	w.nested("if "+s.Cond.String(), s.Then)
+	els := s.Else
+	for len(els) > 0 {
+		// Write "else if" rather than an if inside an else block
+		elseIf, ok := els[0].(*If)
+		if !ok || len(els) > 1 {
+			w.nested("} else", els)
+			break
+		}
+		w.nested("} else if "+elseIf.Cond.String(), elseIf.Then)
+		els = elseIf.Else
+	}
+	w.line("}")
+}
+
+func (s *While) write(w *writer) {
+	w.nested("while "+s.Cond.String(), s.Body)
+	w.line("}")
+}
+
+func (s *Loop) write(w *writer) {
+	w.nested("loop", s.Body)
+	w.line("}")
+}
+
+func (s *Label) write(w *writer) {
+	// Labels in blocks stand out from the statements around them
+	indent := w.indent
+	if indent > 0 {
+		w.indent--
+	}
+	w.line(s.Name + ":")
+	w.indent = indent
+}
+
+func (s *Goto) write(w *writer) {
+	w.line("goto " + s.Label)
+}
@@ -0,0 +1,264 @@
+package lang
+
+import (
+	"fmt"
+
+	"github.com/arnodel/gobot2flags/model"
+	"github.com/arnodel/gobot2flags/solver"
+)
+
+var commandChips = map[model.Command]model.ChipType{
+	model.MoveForward: model.ForwardChip,
+	model.TurnLeft:    model.TurnLeftChip,
+	model.TurnRight:   model.TurnRightChip,
+	model.PaintRed:    model.PaintRedChip,
+	model.PaintYellow: model.PaintYellowChip,
+	model.PaintBlue:   model.PaintBlueChip,
+}
+
+// Compile places the chips of the program on a circuit board of the given
+// size, usually the size of the board of a level (see model.Level.BoardSize),
+// and connects them with arrows.  It returns an error if the program does
+// not fit on the board.
+func Compile(p *Program, width, height int) (*model.CircuitBoard, error) {
+	c := newCompiler()
+	if err := c.defineLabels(p.Body); err != nil {
+		return nil, err
+	}
+	c.nodes[startNode].exits[0] = c.block(p.Body, stopSink)
+	nodes := c.layoutNodes()
+	if len(nodes) <= width*height {
+		for _, start := range startPositions(width, height) {
+			if b, ok := solver.Layout(nodes, width, height, start); ok {
+				return b, nil
+			}
+		}
+	}
+	chips := 0
+	for _, n := range nodes {
+		if n.Chip != model.StartChip && n.Chip != model.NoChip {
+			chips++
+		}
+	}
+	return nil, fmt.Errorf("the program (%d chips) does not fit on a %dx%d board", chips, width, height)
+}
+
+// CompileString parses the text of a program and compiles it.
+func CompileString(src string, width, height int) (*model.CircuitBoard, error) {
+	p, err := Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return Compile(p, width, height)
+}
+
+// The first nodes of the graph built by the compiler.
+const (
+	startNode = 0
+	stopSink  = 1 // An empty slot, for commands that end the run
+)
+
+// A compileNode is a chip, or a jump that is resolved before laying out
+// chips.  Loops and labels start as jumps as they are compiled before the
+// statements that come after them.
+type compileNode struct {
+	chip  model.ChipType
+	exits [2]int // Next nodes if yes and if no
+	jump  bool   // Go to exits[0]
+}
+
+type compiler struct {
+	nodes  []compileNode
+	labels map[string]int // The jump for each label
+}
+
+func newCompiler() *compiler {
+	return &compiler{
+		nodes: []compileNode{
+			startNode: {chip: model.StartChip},
+			stopSink:  {chip: model.NoChip},
+		},
+		labels: map[string]int{},
+	}
+}
+
+func (c *compiler) add(n compileNode) int {
+	c.nodes = append(c.nodes, n)
+	return len(c.nodes) - 1
+}
+
+func (c *compiler) newJump() int {
+	return c.add(compileNode{jump: true})
+}
+
+// defineLabels creates a jump for each label, so that gotos can use labels
+// before they are compiled.
+func (c *compiler) defineLabels(stmts []Stmt) error {
+	var err error
+	forEachStmt(stmts, func(s Stmt) {
+		if l, ok := s.(*Label); ok && err == nil {
+			if _, ok := c.labels[l.Name]; ok {
+				err = fmt.Errorf("label %q defined twice", l.Name)
+			}
+			c.labels[l.Name] = c.newJump()
+		}
+	})
+	forEachStmt(stmts, func(s Stmt) {
+		if g, ok := s.(*Goto); ok && err == nil {
+			if _, ok := c.labels[g.Label]; !ok {
+				err = fmt.Errorf("undefined label %q", g.Label)
+			}
+		}
+	})
+	return err
+}
+
+// forEachStmt calls f for each statement, including the ones in blocks.
+func forEachStmt(stmts []Stmt, f func(Stmt)) {
+	for _, s := range stmts {
+		f(s)
+		switch s := s.(type) {
+		case *If:
+			forEachStmt(s.Then, f)
+			forEachStmt(s.Else, f)
+		case *While:
+			forEachStmt(s.Body, f)
+		case *Loop:
+			forEachStmt(s.Body, f)
+		}
+	}
+}
+
+// block compiles the statements, followed by the node next, and returns the
+// node the statements start with.
+func (c *compiler) block(stmts []Stmt, next int) int {
+	for i := len(stmts) - 1; i >= 0; i-- {
+		next = c.stmt(stmts[i], next)
+	}
+	return next
+}
+
+func (c *compiler) stmt(s Stmt, next int) int {
+	switch s := s.(type) {
+	case *Command:
+		return c.add(compileNode{chip: commandChips[s.Com], exits: [2]int{next}})
+	case *Stop:
+		return stopSink
+	case *If:
+		return c.test(s.Cond, c.block(s.Then, next), c.block(s.Else, next))
+	case *While:
+		head := c.newJump()
+		c.nodes[head].exits[0] = c.test(s.Cond, c.block(s.Body, head), next)
+		return head
+	case *Loop:
+		head := c.newJump()
+		c.nodes[head].exits[0] = c.block(s.Body, head)
+		return head
+	case *Label:
+		l := c.labels[s.Name]
+		c.nodes[l].exits[0] = next
+		return l
+	case *Goto:
+		return c.labels[s.Label]
+	default:
+		panic(fmt.Sprintf("unknown statement %T", s))
+	}
+}
+
+func (c *compiler) test(cond Cond, yes, no int) int {
+	if cond.Not {
+		yes, no = no, yes
+	}
+	return c.add(compileNode{chip: cond.Test, exits: [2]int{yes, no}})
+}
+
+// target returns the chip the flow gets to from node n, following jumps.
+// Going round in circles without reaching a chip stops the run, like on a
+// circuit board.
+func (c *compiler) target(n int) int {
+	seen := map[int]bool{}
+	for c.nodes[n].jump {
+		if seen[n] {
+			return stopSink
+		}
+		seen[n] = true
+		n = c.nodes[n].exits[0]
+	}
+	return n
+}
+
+// removeUselessTests turns tests that go to the same place either way into
+// jumps.
+func (c *compiler) removeUselessTests() {
+	for changed := true; changed; {
+		changed = false
+		for i := range c.nodes {
+			n := &c.nodes[i]
+			if !n.jump && n.chip.IsDecision() && c.target(n.exits[0]) == c.target(n.exits[1]) {
+				n.jump = true
+				changed = true
+			}
+		}
+	}
+}
+
+// layoutNodes returns the chips reachable from the start chip, with their
+// exits resolved, for solver.Layout.
+func (c *compiler) layoutNodes() []solver.LayoutNode {
+	c.removeUselessTests()
+	index := map[int]int{startNode: 0}
+	nodes := []solver.LayoutNode{{Chip: model.StartChip, Exits: [2]int{-1, -1}}}
+	queue := []int{startNode}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		node := c.nodes[n]
+		exits := 1
+		if node.chip.IsDecision() {
+			exits = 2
+		}
+		for i := 0; i < exits; i++ {
+			next := c.target(node.exits[i])
+			if next == stopSink && (node.chip == model.StartChip || node.chip.IsDecision()) {
+				// No arrow stops the run.  Command chips point to an empty
+				// slot instead, otherwise they would not give their command.
+				continue
+			}
+			m, ok := index[next]
+			if !ok {
+				m = len(nodes)
+				index[next] = m
+				nodes = append(nodes, solver.LayoutNode{Chip: c.nodes[next].chip, Exits: [2]int{-1, -1}})
+				if next != stopSink {
+					queue = append(queue, next)
+				}
+			}
+			nodes[index[n]].Exits[i] = m
+		}
+	}
+	return nodes
+}
+
+// startPositions returns the positions to try for the start chip, from the
+// centre of the board outwards.
+func startPositions(width, height int) []model.Position {
+	var positions []model.Position
+	cx, cy := width/2, height/2
+	for d := 0; d < width+height; d++ {
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				if abs(x-cx)+abs(y-cy) == d {
+					positions = append(positions, model.Position{X: x, Y: y})
+				}
+			}
+		}
+	}
+	return positions
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,141 @@
+package lang
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+func TestMain(m *testing.M) {
+	// model.RunLevel logs every step
+	log.SetOutput(ioutil.Discard)
+	os.Exit(m.Run())
+}
+
+const uTurnLevel = `
++--+--+--+
+|R> R  R |
++--+--+  +
+|RF R  R |
++--+--+--+`
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{name: "Empty", src: ""},
+		{name: "Commands", src: "forward\nright\npaint red\n"},
+		{
+			name: "Wall follower",
+			src: `loop {
+    if wall {
+        right
+    } else {
+        forward
+    }
+}
+`,
+		},
+		{
+			name: "While",
+			src: `while not wall {
+    forward
+}
+left
+`,
+		},
+		{
+			name: "Else if",
+			src: `if wall {
+    left
+} else if floor red {
+    paint yellow
+} else {
+    forward
+}
+`,
+		},
+		{
+			name: "Goto",
+			src: `loop {
+    forward
+    if floor red {
+        goto L1
+    }
+}
+L1:
+paint blue
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := CompileString(tt.src, 9, 9)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := model.CircuitBoardFromString(b.String()); err != nil {
+				t.Fatalf("invalid board: %s\n%s", err, b)
+			}
+			// These programs are written the way Decompile writes them
+			p, err := Decompile(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.String(); got != tt.src {
+				t.Errorf("board:\n%s\ndecompiles to:\n%s", b, got)
+			}
+		})
+	}
+}
+
+func TestCompile_Run(t *testing.T) {
+	level, err := model.LevelFromString("u-turn", uTurnLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, h := level.BoardSize()
+	b, err := CompileString("loop { if wall { right } else { forward } }", w, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := model.RunLevel(level, b, 100); result.Outcome != model.Won || result.Steps != 7 {
+		t.Errorf("got %s after %d steps, want won after 7", result.Outcome, result.Steps)
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		prog *Program
+		want string
+	}{
+		{
+			name: "Too big",
+			prog: &Program{Body: []Stmt{
+				&Command{Com: model.MoveForward},
+				&Command{Com: model.TurnLeft},
+				&Command{Com: model.MoveForward},
+				&Command{Com: model.TurnRight},
+			}},
+			want: "the program (4 chips) does not fit on a 2x2 board",
+		},
+		{
+			name: "Undefined label",
+			prog: &Program{Body: []Stmt{&Goto{Label: "x"}}},
+			want: `undefined label "x"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(tt.prog, 2, 2)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("got error %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
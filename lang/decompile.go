@@ -0,0 +1,490 @@
+package lang
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+// Special node numbers in the control flow graph of a board.
+const (
+	stopNode = -1 // The run stops
+	noNode   = -2
+)
+
+// A graph is the control flow of a board with only the chips that give
+// commands and the decision chips.  The start chip and chips that stop the
+// run are resolved away.
+type graph struct {
+	nodes []graphNode
+	entry int
+}
+
+type graphNode struct {
+	pos  model.Position
+	chip model.ChipType
+	succ []int // One successor, or yes and no for decision chips
+}
+
+// Decompile turns the control flow of a circuit board into a structured
+// program, with if, while and loop statements where possible and labels and
+// gotos where the flow cannot be structured.
+func Decompile(b *model.CircuitBoard) (*Program, error) {
+	flow := model.NewBoardFlow(b)
+	if flow.Start() == nil {
+		return nil, errors.New("the board has no start chip")
+	}
+	g := newGraph(flow)
+	d := newDecompiler(g)
+	body := d.seq(g.entry, noNode)
+	// Nodes that are only reached with goto are added at the end.  The
+	// statements above never fall through to them.
+	orphans := false
+	for i := 0; i < len(d.gotos); i++ {
+		if n := d.gotos[i].node; !d.emitted[n] {
+			body = append(body, d.seq(n, noNode)...)
+			orphans = true
+		}
+	}
+	if !orphans {
+		body = dropFinalStops(body)
+	}
+	p := &Program{Body: body}
+	d.nameLabels(p)
+	return p, nil
+}
+
+func newGraph(flow *model.BoardFlow) *graph {
+	g := &graph{}
+	index := map[model.Position]int{}
+	for _, c := range flow.Chips {
+		switch {
+		case c.Type == model.StartChip, !c.Reachable:
+			continue
+		case !c.Type.IsDecision() && c.Branches[0].StopsAtChip():
+			// The command is not given, the run stops here
+			continue
+		}
+		index[c.Pos] = len(g.nodes)
+		g.nodes = append(g.nodes, graphNode{pos: c.Pos, chip: c.Type})
+	}
+
+	// resolve returns the node the flow gets to when it reaches pos
+	resolve := func(pos model.Position) int {
+		for seen := map[model.Position]bool{}; !seen[pos]; {
+			seen[pos] = true
+			if n, ok := index[pos]; ok {
+				return n
+			}
+			c := flow.ChipAt(pos)
+			if c.Type != model.StartChip || c.Branches[0].End != model.ToChip {
+				return stopNode
+			}
+			pos = c.Branches[0].To
+		}
+		// The start chip leads back to itself
+		return stopNode
+	}
+
+	for i := range g.nodes {
+		n := &g.nodes[i]
+		for _, br := range flow.ChipAt(n.pos).Branches {
+			next := stopNode
+			if br.End == model.ToChip {
+				next = resolve(br.To)
+			}
+			n.succ = append(n.succ, next)
+		}
+	}
+	g.entry = resolve(flow.Start().Pos)
+	return g
+}
+
+type decompiler struct {
+	g *graph
+
+	emitted  []bool
+	reserved []bool // Nodes that will be emitted after the current statement
+	gotos    []*Goto
+
+	backEdge [][]bool // backEdge[n][i] is true if succ[i] of n is a back edge
+	loopBody [][]bool // The natural loop of each loop header, or nil
+	ipdom    []int    // Immediate post-dominators ignoring back edges
+}
+
+func newDecompiler(g *graph) *decompiler {
+	n := len(g.nodes)
+	d := &decompiler{
+		g:        g,
+		emitted:  make([]bool, n),
+		reserved: make([]bool, n),
+		backEdge: make([][]bool, n),
+		loopBody: make([][]bool, n),
+	}
+	for i := range g.nodes {
+		d.backEdge[i] = make([]bool, len(g.nodes[i].succ))
+	}
+	d.findLoops()
+	d.findPostDominators()
+	return d
+}
+
+// findLoops finds the back edges with a depth first search and the natural
+// loops of the headers that dominate the source of their back edges.
+// Retreating edges to nodes that do not dominate their source (irreducible
+// flow) are not loops, they become gotos.
+func (d *decompiler) findLoops() {
+	g := d.g
+	if g.entry < 0 {
+		return
+	}
+	onStack := make([]bool, len(g.nodes))
+	visited := make([]bool, len(g.nodes))
+	var dfs func(n int)
+	dfs = func(n int) {
+		visited[n], onStack[n] = true, true
+		for i, s := range g.nodes[n].succ {
+			switch {
+			case s < 0:
+			case onStack[s]:
+				d.backEdge[n][i] = true
+			case !visited[s]:
+				dfs(s)
+			}
+		}
+		onStack[n] = false
+	}
+	dfs(g.entry)
+
+	dom := d.dominators()
+	preds := make([][]int, len(g.nodes))
+	for n, node := range g.nodes {
+		for _, s := range node.succ {
+			if s >= 0 {
+				preds[s] = append(preds[s], n)
+			}
+		}
+	}
+	for n, node := range g.nodes {
+		for i, h := range node.succ {
+			if !d.backEdge[n][i] || !dom[n][h] {
+				continue
+			}
+			if d.loopBody[h] == nil {
+				d.loopBody[h] = make([]bool, len(g.nodes))
+				d.loopBody[h][h] = true
+			}
+			// Add the nodes that reach n without going through h
+			body := d.loopBody[h]
+			stack := []int{n}
+			for len(stack) > 0 {
+				m := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				if body[m] {
+					continue
+				}
+				body[m] = true
+				stack = append(stack, preds[m]...)
+			}
+		}
+	}
+}
+
+// dominators returns dom such that dom[n][m] is true if m dominates n, i.e.
+// every path from the entry to n goes through m.
+func (d *decompiler) dominators() [][]bool {
+	g := d.g
+	n := len(g.nodes)
+	dom := make([][]bool, n)
+	for i := range dom {
+		dom[i] = make([]bool, n)
+		for j := range dom[i] {
+			dom[i][j] = i != g.entry || j == g.entry
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for m, node := range g.nodes {
+			for _, s := range node.succ {
+				if s < 0 || s == g.entry {
+					continue
+				}
+				// dom[s] is the intersection of dom[p] for its predecessors
+				// p, plus s itself
+				for j := range dom[s] {
+					if dom[s][j] && !dom[m][j] && j != s {
+						dom[s][j] = false
+						changed = true
+					}
+				}
+			}
+		}
+	}
+	return dom
+}
+
+// findPostDominators works out the immediate post-dominator of each node,
+// where the flow joins again after an if statement.  Back edges count as
+// leaving the graph so that this also works inside loops.
+func (d *decompiler) findPostDominators() {
+	g := d.g
+	n := len(g.nodes)
+	exit := n
+	pdom := make([][]bool, n+1)
+	for i := range pdom {
+		pdom[i] = make([]bool, n+1)
+		for j := range pdom[i] {
+			pdom[i][j] = i != exit || j == exit
+		}
+	}
+	succ := func(m, i int) int {
+		if s := g.nodes[m].succ[i]; s >= 0 && !d.backEdge[m][i] {
+			return s
+		}
+		return exit
+	}
+	for changed := true; changed; {
+		changed = false
+		for m, node := range g.nodes {
+			for j := range pdom[m] {
+				if !pdom[m][j] || j == m {
+					continue
+				}
+				for i := range node.succ {
+					if !pdom[succ(m, i)][j] {
+						pdom[m][j] = false
+						changed = true
+						break
+					}
+				}
+			}
+		}
+	}
+	d.ipdom = make([]int, n)
+	for m := range g.nodes {
+		d.ipdom[m] = noNode
+		// The immediate post-dominator is the one post-dominated by all the
+		// others, i.e. the one with the most post-dominators.
+		best := -1
+		for j := 0; j < n; j++ {
+			if j != m && pdom[m][j] && (best < 0 || count(pdom[j]) > count(pdom[best])) {
+				best = j
+			}
+		}
+		if best >= 0 {
+			d.ipdom[m] = best
+		}
+	}
+}
+
+func count(s []bool) int {
+	c := 0
+	for _, b := range s {
+		if b {
+			c++
+		}
+	}
+	return c
+}
+
+// seq returns the statements for the flow from node n until it gets to
+// follow, where the enclosing statement continues.
+func (d *decompiler) seq(n, follow int) []Stmt {
+	var out []Stmt
+	for {
+		switch {
+		case n == stopNode:
+			return append(out, &Stop{})
+		case n == follow:
+			return out
+		case d.emitted[n] || d.reserved[n]:
+			return append(out, d.gotoNode(n))
+		}
+		d.emitted[n] = true
+		out = append(out, &Label{node: n})
+		var more bool
+		if d.loopBody[n] != nil {
+			out, n, more = d.loop(out, n, follow)
+		} else {
+			out, n, more = d.node(out, n, follow)
+		}
+		if !more {
+			return out
+		}
+	}
+}
+
+// node adds the statement for node n to out and returns the node to continue
+// from, and false if the flow does not continue after the statement.
+func (d *decompiler) node(out []Stmt, n, follow int) ([]Stmt, int, bool) {
+	node := d.g.nodes[n]
+	if !node.chip.IsDecision() {
+		com, _ := model.Chip(node.chip).Command(model.NoColor, false)
+		return append(out, &Command{Com: com}), node.succ[0], true
+	}
+	join := d.ipdom[n]
+	switch {
+	case join == noNode || join == follow:
+		join = follow
+	case d.emitted[join] || d.reserved[join]:
+		join = noNode
+	default:
+		d.reserved[join] = true
+		defer func() { d.reserved[join] = false }()
+	}
+	stmt := &If{
+		Cond: Cond{Test: node.chip},
+		Then: d.seq(node.succ[0], join),
+		Else: d.seq(node.succ[1], join),
+	}
+	return append(out, simplifyIf(stmt)...), join, join != noNode
+}
+
+// simplifyIf removes the if statement if both branches are empty and negates
+// its condition if only the then branch is empty.
+func simplifyIf(s *If) []Stmt {
+	switch {
+	case len(s.Then) == 0 && len(s.Else) == 0:
+		// The test makes no difference
+		return nil
+	case len(s.Then) == 0:
+		s.Cond.Not = !s.Cond.Not
+		s.Then, s.Else = s.Else, nil
+	}
+	return []Stmt{s}
+}
+
+// dropFinalStops removes the stop statements at the end of the program, as
+// the run stops there anyway.
+func dropFinalStops(stmts []Stmt) []Stmt {
+	n := len(stmts)
+	if n == 0 {
+		return stmts
+	}
+	switch s := stmts[n-1].(type) {
+	case *Stop:
+		return dropFinalStops(stmts[:n-1])
+	case *If:
+		s.Then = dropFinalStops(s.Then)
+		s.Else = dropFinalStops(s.Else)
+		return append(stmts[:n-1], simplifyIf(s)...)
+	}
+	return stmts
+}
+
+// loop adds a while or loop statement for the natural loop with header h.
+func (d *decompiler) loop(out []Stmt, h, follow int) ([]Stmt, int, bool) {
+	var (
+		body       = d.loopBody[h]
+		header     = d.g.nodes[h]
+		exits      []int
+		headerOnly = true // All exits leave from the header
+	)
+	for m, node := range d.g.nodes {
+		if !body[m] {
+			continue
+		}
+		for _, s := range node.succ {
+			if s < 0 || !body[s] {
+				exits = append(exits, s)
+				headerOnly = headerOnly && m == h
+			}
+		}
+	}
+
+	if header.chip.IsDecision() && len(exits) == 1 && headerOnly {
+		in, exit := 0, 1
+		if s := header.succ[1]; s >= 0 && body[s] {
+			in, exit = 1, 0
+		}
+		x := header.succ[exit]
+		d.reserve(x)
+		stmt := &While{
+			Cond: Cond{Test: header.chip, Not: in == 1},
+			Body: d.seq(header.succ[in], h),
+		}
+		d.unreserve(x)
+		return append(out, stmt), x, true
+	}
+
+	// A loop that cannot be written as a while loop.  It can only be left
+	// with goto.
+	for _, x := range exits {
+		d.reserve(x)
+	}
+	stmts, next, more := d.node(nil, h, h)
+	if more {
+		stmts = append(stmts, d.seq(next, h)...)
+	}
+	for _, x := range exits {
+		d.unreserve(x)
+	}
+	out = append(out, &Loop{Body: stmts})
+	for _, x := range exits {
+		if x >= 0 && !d.emitted[x] {
+			// Carry on with one of the exits, the others are added at the
+			// end of the program
+			return out, x, true
+		}
+	}
+	return out, noNode, false
+}
+
+func (d *decompiler) reserve(n int) {
+	if n >= 0 {
+		d.reserved[n] = true
+	}
+}
+
+func (d *decompiler) unreserve(n int) {
+	if n >= 0 {
+		d.reserved[n] = false
+	}
+}
+
+func (d *decompiler) gotoNode(n int) *Goto {
+	s := &Goto{node: n}
+	d.gotos = append(d.gotos, s)
+	return s
+}
+
+// nameLabels names the labels used by gotos, in the order they appear, and
+// removes the other ones.
+func (d *decompiler) nameLabels(p *Program) {
+	used := map[int]bool{}
+	for _, s := range d.gotos {
+		used[s.node] = true
+	}
+	names := map[int]string{}
+	var clean func([]Stmt) []Stmt
+	clean = func(stmts []Stmt) []Stmt {
+		var out []Stmt
+		for _, s := range stmts {
+			switch s := s.(type) {
+			case *Label:
+				if !used[s.node] {
+					continue
+				}
+				s.Name = fmt.Sprintf("L%d", len(names)+1)
+				names[s.node] = s.Name
+			case *If:
+				// The branches may only have had unused labels
+				s.Then = clean(s.Then)
+				s.Else = clean(s.Else)
+				out = append(out, simplifyIf(s)...)
+				continue
+			case *While:
+				s.Body = clean(s.Body)
+			case *Loop:
+				s.Body = clean(s.Body)
+			}
+			out = append(out, s)
+		}
+		return out
+	}
+	p.Body = clean(p.Body)
+	for _, s := range d.gotos {
+		s.Label = names[s.node]
+	}
+}
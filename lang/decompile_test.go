@@ -0,0 +1,151 @@
+package lang
+
+import (
+	"testing"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+func TestDecompile(t *testing.T) {
+	tests := []struct {
+		name  string
+		board string
+		want  string
+	}{
+		{
+			name:  "Straight line",
+			board: `|ST -> MF -> TL -> PR|`,
+			want: `forward
+left
+`,
+		},
+		{
+			name: "Forward loop",
+			board: `
+|ST -> MF|
+| ^     v|
+|.. <- ..|`,
+			want: `loop {
+    forward
+}
+`,
+		},
+		{
+			name: "Wall follower",
+			board: `
+|ST -> W? n> MF|
+| ^    yv     v|
+|.. <- TL    ..|
+| ^           v|
+|.. <- .. <- ..|`,
+			want: `loop {
+    if wall {
+        left
+    } else {
+        forward
+    }
+}
+`,
+		},
+		{
+			name: "While",
+			board: `
+|ST -> W? y> TL -> TR|
+| ^    nv            |
+|.. <- MF            |`,
+			want: `while not wall {
+    forward
+}
+left
+`,
+		},
+		{
+			name: "Stop",
+			board: `
+|ST -> MF -> R? y> TL|
+|       ^    nv      |
+|      .. <- ..      |`,
+			want: `loop {
+    forward
+    if floor red {
+        stop
+    }
+}
+`,
+		},
+		{
+			name: "Final stops",
+			board: `
+|ST -> R? n> PR -> MF|
+|      yv           v|
+|      TL          ..|`,
+			want: `if not floor red {
+    paint red
+    forward
+}
+`,
+		},
+		{
+			name: "Loop exit",
+			board: `
+|ST -> MF -> W? y> TL -> TR|
+|       ^    nv            |
+|      .. <- ..            |`,
+			want: `loop {
+    forward
+    if wall {
+        goto L1
+    }
+}
+L1:
+left
+`,
+		},
+		{
+			name: "Irreducible",
+			board: `
+|ST -> W? y> MF -> ..|
+|      nv     ^     v|
+|      TL -> ..    ..|
+|       ^           v|
+|      .. <- .. <- ..|`,
+			want: `if wall {
+L1:
+    forward
+}
+left
+goto L1
+`,
+		},
+		{
+			name: "Unreachable chips",
+			board: `
+|ST -> MF -> PR    TL|
+|                   v|
+|                  TR|`,
+			want: `forward
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := model.CircuitBoardFromString(tt.board)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, err := Decompile(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.String(); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecompile_NoStart(t *testing.T) {
+	if _, err := Decompile(model.NewCircuitBoard(2, 2)); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,302 @@
+package lang
+
+import (
+	"fmt"
+	"unicode"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+// Parse parses the text of a program.  Errors are *model.ParseError values
+// giving the line and column of the problem.
+func Parse(src string) (*Program, error) {
+	p := &parser{src: []rune(src), line: 1, col: 1}
+	var prog *Program
+	err := p.catch(func() {
+		p.next()
+		prog = &Program{Body: p.stmts()}
+		if p.tok.kind != eofTok {
+			p.fail("unexpected %s", p.tok)
+		}
+		p.checkLabels()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return prog, nil
+}
+
+type tokenKind int
+
+const (
+	eofTok tokenKind = iota
+	wordTok
+	lbraceTok
+	rbraceTok
+	colonTok
+)
+
+type token struct {
+	kind      tokenKind
+	text      string
+	line, col int
+}
+
+func (t token) String() string {
+	if t.kind == eofTok {
+		return "end of program"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+type parser struct {
+	src       []rune
+	i         int
+	line, col int
+	tok       token
+
+	labels []token // Labels defined in the program
+	gotos  []token // Labels used by gotos
+}
+
+// catch runs f and returns the error it fails with, if any.
+func (p *parser) catch(f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			perr, ok := r.(*model.ParseError)
+			if !ok {
+				panic(r)
+			}
+			err = perr
+		}
+	}()
+	f()
+	return nil
+}
+
+func (p *parser) failAt(t token, format string, args ...interface{}) {
+	panic(&model.ParseError{Line: t.line, Col: t.col, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (p *parser) fail(format string, args ...interface{}) {
+	p.failAt(p.tok, format, args...)
+}
+
+// next reads the next token into p.tok.
+func (p *parser) next() {
+	for p.i < len(p.src) {
+		r := p.src[p.i]
+		if r == '#' {
+			for p.i < len(p.src) && p.src[p.i] != '\n' {
+				p.advance()
+			}
+		} else if unicode.IsSpace(r) {
+			p.advance()
+		} else {
+			break
+		}
+	}
+	p.tok = token{line: p.line, col: p.col}
+	if p.i == len(p.src) {
+		p.tok.kind = eofTok
+		return
+	}
+	start := p.i
+	switch r := p.src[p.i]; {
+	case r == '{':
+		p.tok.kind = lbraceTok
+		p.advance()
+	case r == '}':
+		p.tok.kind = rbraceTok
+		p.advance()
+	case r == ':':
+		p.tok.kind = colonTok
+		p.advance()
+	case isWordRune(r):
+		p.tok.kind = wordTok
+		for p.i < len(p.src) && isWordRune(p.src[p.i]) {
+			p.advance()
+		}
+	default:
+		p.fail("unexpected character %q", r)
+	}
+	p.tok.text = string(p.src[start:p.i])
+}
+
+func (p *parser) advance() {
+	if p.src[p.i] == '\n' {
+		p.line++
+		p.col = 0
+	}
+	p.i++
+	p.col++
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// stmts parses statements until a closing brace or the end of the program.
+func (p *parser) stmts() []Stmt {
+	var stmts []Stmt
+	for p.tok.kind != eofTok && p.tok.kind != rbraceTok {
+		stmts = append(stmts, p.stmt())
+	}
+	return stmts
+}
+
+func (p *parser) stmt() Stmt {
+	t := p.tok
+	if t.kind != wordTok {
+		p.fail("expected a statement, got %s", t)
+	}
+	p.next()
+	switch t.text {
+	case "forward":
+		return &Command{Com: model.MoveForward}
+	case "left":
+		return &Command{Com: model.TurnLeft}
+	case "right":
+		return &Command{Com: model.TurnRight}
+	case "paint":
+		return &Command{Com: p.paintCommand()}
+	case "stop":
+		return &Stop{}
+	case "if":
+		return p.ifStmt()
+	case "while":
+		cond := p.cond()
+		return &While{Cond: cond, Body: p.block()}
+	case "loop":
+		return &Loop{Body: p.block()}
+	case "goto":
+		label := p.name()
+		p.gotos = append(p.gotos, label)
+		return &Goto{Label: label.text}
+	}
+	if p.tok.kind == colonTok {
+		if isKeyword(t.text) {
+			p.failAt(t, "%s cannot be used as a label", t)
+		}
+		p.next()
+		p.labels = append(p.labels, t)
+		return &Label{Name: t.text}
+	}
+	p.failAt(t, "unknown statement %s", t)
+	return nil
+}
+
+// ifStmt parses an if statement after the "if" keyword.
+func (p *parser) ifStmt() Stmt {
+	s := &If{Cond: p.cond(), Then: p.block()}
+	if p.tok.kind == wordTok && p.tok.text == "else" {
+		p.next()
+		if p.tok.kind == wordTok && p.tok.text == "if" {
+			p.next()
+			s.Else = []Stmt{p.ifStmt()}
+		} else {
+			s.Else = p.block()
+		}
+	}
+	return s
+}
+
+func (p *parser) block() []Stmt {
+	if p.tok.kind != lbraceTok {
+		p.fail("expected \"{\", got %s", p.tok)
+	}
+	p.next()
+	stmts := p.stmts()
+	if p.tok.kind != rbraceTok {
+		p.fail("expected \"}\", got %s", p.tok)
+	}
+	p.next()
+	return stmts
+}
+
+func (p *parser) cond() Cond {
+	var c Cond
+	if p.word() == "not" {
+		c.Not = true
+		p.next()
+	}
+	switch p.word() {
+	case "wall":
+		c.Test = model.IsWallAheadChip
+	case "floor":
+		p.next()
+		switch p.word() {
+		case "red":
+			c.Test = model.IsFloorRedChip
+		case "yellow":
+			c.Test = model.IsFloorYellowChip
+		case "blue":
+			c.Test = model.IsFloorBlueChip
+		default:
+			p.fail("expected red, yellow or blue, got %s", p.tok)
+		}
+	default:
+		p.fail("expected a condition, got %s", p.tok)
+	}
+	p.next()
+	return c
+}
+
+func (p *parser) paintCommand() model.Command {
+	var com model.Command
+	switch p.word() {
+	case "red":
+		com = model.PaintRed
+	case "yellow":
+		com = model.PaintYellow
+	case "blue":
+		com = model.PaintBlue
+	default:
+		p.fail("expected red, yellow or blue, got %s", p.tok)
+	}
+	p.next()
+	return com
+}
+
+func (p *parser) name() token {
+	t := p.tok
+	if t.kind != wordTok || isKeyword(t.text) {
+		p.fail("expected a label, got %s", t)
+	}
+	p.next()
+	return t
+}
+
+// word returns the text of the current token if it is a word, "" otherwise.
+func (p *parser) word() string {
+	if p.tok.kind != wordTok {
+		return ""
+	}
+	return p.tok.text
+}
+
+// checkLabels checks that labels are defined once and that gotos use them.
+func (p *parser) checkLabels() {
+	defined := map[string]bool{}
+	for _, t := range p.labels {
+		if defined[t.text] {
+			p.failAt(t, "label %s defined twice", t)
+		}
+		defined[t.text] = true
+	}
+	for _, t := range p.gotos {
+		if !defined[t.text] {
+			p.failAt(t, "undefined label %s", t)
+		}
+	}
+}
+
+var keywords = map[string]bool{
+	"forward": true, "left": true, "right": true, "paint": true, "stop": true,
+	"if": true, "else": true, "while": true, "loop": true, "goto": true,
+	"not": true, "wall": true, "floor": true,
+}
+
+func isKeyword(s string) bool {
+	return keywords[s]
+}
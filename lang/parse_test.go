@@ -0,0 +1,93 @@
+package lang
+
+import (
+	"testing"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "Commands",
+			src:  "forward left right paint red paint yellow paint blue stop",
+			want: "forward\nleft\nright\npaint red\npaint yellow\npaint blue\nstop\n",
+		},
+		{
+			name: "Blocks",
+			src: `
+# Follow the wall on the left
+loop {
+	if not wall { forward } else if floor blue { right }
+	else { while wall { left } }
+}`,
+			want: `loop {
+    if not wall {
+        forward
+    } else if floor blue {
+        right
+    } else {
+        while wall {
+            left
+        }
+    }
+}
+`,
+		},
+		{
+			name: "Labels",
+			src:  "again: forward goto again",
+			want: "again:\nforward\ngoto again\n",
+		},
+		{
+			name: "Empty",
+			src:  "  # nothing\n",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.String(); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want model.ParseError
+	}{
+		{"jump", model.ParseError{Line: 1, Col: 1, Msg: `unknown statement "jump"`}},
+		{"forward\npaint green", model.ParseError{Line: 2, Col: 7, Msg: `expected red, yellow or blue, got "green"`}},
+		{"if wall forward", model.ParseError{Line: 1, Col: 9, Msg: `expected "{", got "forward"`}},
+		{"while {}", model.ParseError{Line: 1, Col: 7, Msg: `expected a condition, got "{"`}},
+		{"loop { left", model.ParseError{Line: 1, Col: 12, Msg: `expected "}", got end of program`}},
+		{"left }", model.ParseError{Line: 1, Col: 6, Msg: `unexpected "}"`}},
+		{"forward;", model.ParseError{Line: 1, Col: 8, Msg: `unexpected character ';'`}},
+		{"goto end", model.ParseError{Line: 1, Col: 6, Msg: `undefined label "end"`}},
+		{"a: a:", model.ParseError{Line: 1, Col: 4, Msg: `label "a" defined twice`}},
+		{"wall: left", model.ParseError{Line: 1, Col: 1, Msg: `"wall" cannot be used as a label`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := Parse(tt.src)
+			perr, ok := err.(*model.ParseError)
+			if !ok {
+				t.Fatalf("got %v, want a *model.ParseError", err)
+			}
+			if *perr != tt.want {
+				t.Errorf("got %q, want %q", perr, &tt.want)
+			}
+		})
+	}
+}
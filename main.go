@@ -1,44 +1,195 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	_ "image/png"
+	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
 
 	"github.com/arnodel/gobot2flags/engine"
+	"github.com/arnodel/gobot2flags/model"
 	"github.com/arnodel/gobot2flags/play"
 	"github.com/arnodel/gobot2flags/resources"
 	"github.com/arnodel/gobot2flags/selectlevel"
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
+// Number of frames between two checks for modified level files (about one
+// second).
+const levelPollFrames = 60
+
 func main() {
+	var levelDir, replayDir, recordInput, replayInput string
+	flag.StringVar(&levelDir, "leveldir", "", "load levels from this directory and reload them when they change")
+	flag.StringVar(&replayDir, "replaydir", "", "save a replay of each run that ends in this directory")
+	flag.StringVar(&recordInput, "recordinput", "", "record the input to this file, to replay it with -replayinput")
+	flag.StringVar(&replayInput, "replayinput", "", "replay the input recorded in this file, then carry on with the live input")
+	flag.Parse()
+
+	if levelDir != "" {
+		resources.UseLevelDir(levelDir)
+	}
 
-	ebiten.SetWindowSize(1024, 768)
+	width, height := 1024, 768
+	ebiten.SetWindowSize(width, height)
 	ebiten.SetWindowTitle("Gobot 2 Flags")
 	ebiten.SetWindowResizable(true)
 
-	game := newGameController()
-	if err := ebiten.RunGame(game); err != nil {
+	game := newGameController(levelDir != "")
+	game.replayDir = replayDir
+
+	var input engine.InputSource = engine.EbitenInput{}
+	if replayInput != "" {
+		data, err := ioutil.ReadFile(replayInput)
+		if err != nil {
+			log.Fatal(err)
+		}
+		recording, err := engine.InputRecordingFromJSON(data)
+		if err != nil {
+			log.Fatal(err)
+		}
+		script, err := recording.Script()
+		if err != nil {
+			log.Fatal(err)
+		}
+		// The positions in the recording only make sense at the same size
+		width, height = recording.Width, recording.Height
+		ebiten.SetWindowSize(width, height)
+		ebiten.SetWindowResizable(false)
+		input = engine.NewScriptedInput(script).ThenUse(input)
+	}
+
+	if recordInput != "" {
+		f, err := os.Create(recordInput)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ebiten.SetWindowResizable(false)
+		recorder := engine.NewInputRecorder(input)
+		game.recorder, game.recordFile = recorder, f
+		input = recorder
+	}
+	game.SetInput(input)
+
+	err := ebiten.RunGame(game)
+	game.stopRecording()
+	if err != nil && err != errQuit {
 		log.Fatal(err)
 	}
 }
 
+// Returned by Update to end the game.
+var errQuit = errors.New("quit")
+
+// How the screen changes when going to a level and back to the level list.
+var (
+	enterLevelTransition = engine.Transition{Kind: engine.SlideLeft}
+	exitLevelTransition  = engine.Transition{Kind: engine.SlideRight}
+)
+
 type gameController struct {
-	levels     []string
-	selectView engine.View
-	playViews  map[string]engine.View
+	levels    []string
+	playViews map[string]*play.View
+	quitting  bool
 	engine.Game
+
+	// Only used when reloading levels
+	reloadLevels  bool
+	levelModTimes map[string]time.Time
+	frames        int
+
+	replayDir string
+
+	// The input is written to recordFile as it comes, from the first update
+	// so that the size of the screen is known
+	recorder         *engine.InputRecorder
+	recordFile       *os.File
+	recordingStarted bool
 }
 
-func newGameController() *gameController {
+func newGameController(reloadLevels bool) *gameController {
 	c := &gameController{
-		levels:    resources.GetLevelList(),
-		playViews: map[string]engine.View{},
+		levels:        resources.GetLevelList(),
+		playViews:     map[string]*play.View{},
+		reloadLevels:  reloadLevels,
+		levelModTimes: map[string]time.Time{},
 	}
-	c.setSelectView()
+	selectView := selectlevel.NewView(c.levels, c.selectLevel)
+	if runtime.GOOS != "js" {
+		// There is nothing to quit to in a browser
+		selectView.SetExitHandler(c.confirmQuit)
+	}
+	c.SetView(selectView)
 	return c
 }
 
+func (c *gameController) Update() error {
+	if c.recorder != nil {
+		if err := c.startRecording(); err != nil {
+			return err
+		}
+		defer func() {
+			if r := recover(); r != nil {
+				// Keep the number of frames so the crash can be replayed
+				c.stopRecording()
+				panic(r)
+			}
+		}()
+	}
+	if c.reloadLevels {
+		c.frames++
+		if c.frames%levelPollFrames == 0 {
+			c.pollLevels()
+		}
+	}
+	if c.quitting {
+		return errQuit
+	}
+	return c.Game.Update()
+}
+
+// startRecording starts writing the recording of the input, for the size of
+// the screen given by the last call to Layout, which may not be the size of
+// the window that was asked for.
+func (c *gameController) startRecording() error {
+	if c.recordingStarted {
+		return nil
+	}
+	c.recordingStarted = true
+	w, h := c.OutsideSize()
+	return c.recorder.StartWriting(c.recordFile, w, h)
+}
+
+// stopRecording finishes the recording of the input, if there is one.
+func (c *gameController) stopRecording() {
+	if c.recorder == nil {
+		return
+	}
+	c.startRecording() // Close returns its error too
+	err := c.recorder.Close()
+	if cerr := c.recordFile.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		log.Println(err)
+	} else {
+		log.Printf("Saved input recording %s", c.recordFile.Name())
+	}
+	c.recorder = nil
+}
+
+func (c *gameController) confirmQuit() {
+	engine.ShowDialog(c, engine.NewDialog("Quit Gobot 2 Flags?", []string{"Quit", "Cancel"}, func(i int) {
+		c.quitting = i == 0
+	}))
+}
+
 func (c *gameController) selectLevel(i int) {
 	levelName := c.levels[i]
 	level, err := resources.GetLevel(levelName)
@@ -48,15 +199,60 @@ func (c *gameController) selectLevel(i int) {
 	}
 	playView := c.playViews[levelName]
 	if playView == nil {
-		playView = play.NewView(level, c.setSelectView)
+		playView = play.NewView(level, c.exitLevel)
+		if c.replayDir != "" {
+			playView.SetRunEndHandler(c.saveReplay)
+		}
 		c.playViews[levelName] = playView
+		if c.reloadLevels {
+			c.levelModTimes[levelName], _ = resources.GetLevelModTime(levelName)
+		}
+	}
+	c.PushView(playView, enterLevelTransition)
+}
+
+// exitLevel goes back to the level list.
+func (c *gameController) exitLevel() {
+	c.PopView(exitLevelTransition)
+}
+
+// saveReplay writes the replay of a run in the replay directory, in a file
+// named after the level and the time.
+func (c *gameController) saveReplay(replay *model.Replay) {
+	data, err := model.ReplayToJSON(replay)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	name := fmt.Sprintf("%s-%s.replay.json", replay.Level, time.Now().Format("20060102-150405"))
+	path := filepath.Join(c.replayDir, name)
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		log.Println(err)
+		return
 	}
-	c.SetView(playView)
+	log.Printf("Saved replay %s", path)
 }
 
-func (c *gameController) setSelectView() {
-	if c.selectView == nil {
-		c.selectView = selectlevel.NewView(c.levels, c.selectLevel)
+// pollLevels reloads the levels of open play views whose file has been
+// modified since they were last loaded.
+func (c *gameController) pollLevels() {
+	for levelName, playView := range c.playViews {
+		modTime, err := resources.GetLevelModTime(levelName)
+		if err != nil {
+			playView.SetLevelError(err)
+			continue
+		}
+		if modTime.Equal(c.levelModTimes[levelName]) {
+			continue
+		}
+		c.levelModTimes[levelName] = modTime
+		level, err := resources.GetLevel(levelName)
+		if err != nil {
+			log.Println(err)
+			playView.SetLevelError(err)
+			continue
+		}
+		log.Printf("Reloaded level %s", levelName)
+		playView.SetLevel(level)
 	}
-	c.SetView(c.selectView)
 }
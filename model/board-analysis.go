@@ -0,0 +1,102 @@
+package model
+
+import "fmt"
+
+// BoardProblem is a kind of problem found by AnalyzeBoard.
+type BoardProblem int
+
+const (
+	NoStartChip     BoardProblem = iota // The board has no start chip
+	OffBoardArrow                       // An arrow points off the edge of the board
+	DeadEndSlot                         // An arrow leads into an empty slot with no arrow out
+	EmptySlotsCycle                     // Arrows go round empty slots forever
+	MissingBranch                       // A chip has no arrow, or a decision chip has no yes or no arrow
+	UnreachableChip                     // The start chip never leads to the chip
+)
+
+// A BoardWarning is a problem in a circuit board that makes a run stop or
+// is probably a mistake.
+type BoardWarning struct {
+	Problem BoardProblem
+	Pos     Position    // The slot the problem is about, except for NoStartChip
+	Dir     Orientation // The direction of the arrow for OffBoardArrow
+	Chip    ChipType    // The chip at Pos for MissingBranch and UnreachableChip
+	Arrow   ArrowType   // The missing arrow for MissingBranch
+}
+
+func (w BoardWarning) String() string {
+	switch w.Problem {
+	case NoStartChip:
+		return "there is no start chip"
+	case OffBoardArrow:
+		return fmt.Sprintf("the arrow at %s points %s off the board", w.Pos, w.Dir)
+	case DeadEndSlot:
+		return fmt.Sprintf("an arrow leads into the empty slot at %s, which has no arrow out", w.Pos)
+	case EmptySlotsCycle:
+		return fmt.Sprintf("the arrows from the empty slot at %s go round in circles", w.Pos)
+	case MissingBranch:
+		if w.Chip.IsDecision() {
+			return fmt.Sprintf("the %s chip at %s has no %q arrow", w.Chip, w.Pos, w.Arrow)
+		}
+		return fmt.Sprintf("the %s chip at %s has no arrow", w.Chip, w.Pos)
+	case UnreachableChip:
+		return fmt.Sprintf("the %s chip at %s cannot be reached from the start chip", w.Chip, w.Pos)
+	default:
+		return "unknown problem"
+	}
+}
+
+// AnalyzeBoard returns the problems found in the circuit board without
+// running it: a missing start chip, arrows that lead nowhere, missing
+// branches and chips that can never be used.  Arrows across empty slots are
+// fine as long as they lead to a chip.  Warnings about slots come in reading
+// order.
+func AnalyzeBoard(b *CircuitBoard) []BoardWarning {
+	var warnings []BoardWarning
+	if _, ok := b.StartPos(); !ok {
+		warnings = append(warnings, BoardWarning{Problem: NoStartChip})
+	}
+	flow := NewBoardFlow(b)
+	deadEnds := map[Position]BoardProblem{}
+	for _, c := range flow.Chips {
+		for _, br := range c.Branches {
+			switch br.End {
+			case EmptySlot:
+				deadEnds[br.Slots[len(br.Slots)-1]] = DeadEndSlot
+			case EmptySlotsLoop:
+				deadEnds[br.Slots[len(br.Slots)-1]] = EmptySlotsCycle
+			}
+		}
+	}
+	for y := 0; y < b.height; y++ {
+		for x := 0; x < b.width; x++ {
+			pos := Position{x, y}
+			chip := b.ChipAt(x, y)
+			for _, yes := range []bool{true, false} {
+				o, ok := chip.Arrow(yes)
+				if !ok || !yes && !chip.IsTest() {
+					continue
+				}
+				if next := pos.Move(o.VelocityForward()); !b.Contains(next.X, next.Y) {
+					warnings = append(warnings, BoardWarning{Problem: OffBoardArrow, Pos: pos, Dir: o})
+				}
+			}
+			if p, ok := deadEnds[pos]; ok {
+				warnings = append(warnings, BoardWarning{Problem: p, Pos: pos})
+			}
+			c := flow.ChipAt(pos)
+			if c == nil {
+				continue
+			}
+			for _, br := range c.Branches {
+				if br.End == MissingArrow {
+					warnings = append(warnings, BoardWarning{Problem: MissingBranch, Pos: pos, Chip: c.Type, Arrow: br.Arrow})
+				}
+			}
+			if flow.Start() != nil && !c.Reachable {
+				warnings = append(warnings, BoardWarning{Problem: UnreachableChip, Pos: pos, Chip: c.Type})
+			}
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeBoard(t *testing.T) {
+	tests := []struct {
+		name        string
+		board       string
+		removeStart bool
+		want        []string
+	}{
+		{
+			name:  "No problems",
+			board: forwardLoopBoard,
+		},
+		{
+			name:        "No start chip",
+			board:       "|ST -> MF|",
+			removeStart: true,
+			want: []string{
+				"there is no start chip",
+				"the move forward chip at (1, 0) has no arrow",
+			},
+		},
+		{
+			name: "Missing branches and unreachable chip",
+			board: `
+|ST -> W? n> TL|
+|      yv      |
+|TR    ..      |`,
+			want: []string{
+				"the turn left chip at (2, 0) has no arrow",
+				"the turn right chip at (0, 1) has no arrow",
+				"the turn right chip at (0, 1) cannot be reached from the start chip",
+				"an arrow leads into the empty slot at (1, 1), which has no arrow out",
+			},
+		},
+		{
+			name: "Missing no branch",
+			board: `
+|ST -> R? -> MF|
+|       ^     v|
+|      .. <- ..|`,
+			want: []string{`the floor red? chip at (1, 0) has no "no" arrow`},
+		},
+		{
+			name: "Empty slots loop",
+			board: `
+|ST -> .. -> ..|
+|       ^     v|
+|      .. <- ..|`,
+			want: []string{"the arrows from the empty slot at (1, 1) go round in circles"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := CircuitBoardFromString(tt.board)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.removeStart {
+				b.SetChipAt(0, 0, b.ChipAt(0, 0).WithType(NoChip))
+			}
+			var got []string
+			for _, w := range AnalyzeBoard(b) {
+				got = append(got, w.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeBoard_OffBoard(t *testing.T) {
+	// SetChipAt cannot make arrows that point off the board
+	b := &CircuitBoard{
+		width:       2,
+		height:      1,
+		chips:       []Chip{Chip(StartChip).WithArrowYes(East), Chip(ForwardChip).WithArrowYes(East)},
+		hasStartPos: true,
+	}
+	want := []BoardWarning{{Problem: OffBoardArrow, Pos: Position{1, 0}, Dir: East}}
+	if got := AnalyzeBoard(b); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// Running the board stops at the edge instead of panicking, like when
+	// there is no arrow
+	level, err := LevelFromString("straight", straightLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := RunLevel(level, b, 100)
+	if result.Outcome != DeadEnd || result.Steps != 0 {
+		t.Errorf("got %s after %d steps, want dead end", result.Outcome, result.Steps)
+	}
+}
@@ -0,0 +1,151 @@
+package model
+
+// BoardFlow is the control flow of a circuit board: its chips and where their
+// arrows lead, going through empty slots.  Arrows across empty slots only
+// carry the flow from one chip to the next.
+type BoardFlow struct {
+	Chips []*FlowChip // In reading order
+
+	start    *FlowChip
+	chipsPos map[Position]*FlowChip
+}
+
+// A FlowChip is a chip of a circuit board in a BoardFlow.
+type FlowChip struct {
+	Pos       Position
+	Type      ChipType
+	Reachable bool // The chip can be reached from the start chip
+
+	// Branches has one branch for chips that give a command and for the
+	// start chip, and a yes and a no branch for decision chips.
+	Branches []FlowBranch
+}
+
+// A FlowBranch is where an arrow of a chip leads.
+type FlowBranch struct {
+	Arrow ArrowType // ArrowYes for chips that are not decision chips
+	End   FlowEnd
+	To    Position   // The next chip, if End is ToChip
+	Slots []Position // The empty slots the branch goes through
+}
+
+// Dangling returns true if the branch does not lead to a chip.
+func (b FlowBranch) Dangling() bool {
+	return b.End != ToChip
+}
+
+// StopsAtChip returns true if the chip of the branch has no arrow for it or an
+// arrow that points off the board.  The run then stops at the chip, without
+// giving its command.  When a branch goes through empty slots and does not
+// lead to a chip, the command is given before the run stops.
+func (b FlowBranch) StopsAtChip() bool {
+	return b.End == MissingArrow || b.End == OffBoard && len(b.Slots) == 0
+}
+
+// FlowEnd is where a FlowBranch ends.
+type FlowEnd int
+
+const (
+	ToChip         FlowEnd = iota // The branch leads to a chip
+	MissingArrow                  // The chip has no arrow for the branch
+	EmptySlot                     // The branch leads to an empty slot with no arrow
+	OffBoard                      // An arrow points off the board
+	EmptySlotsLoop                // The branch goes round empty slots forever
+)
+
+func (e FlowEnd) String() string {
+	switch e {
+	case ToChip:
+		return "chip"
+	case MissingArrow:
+		return "missing arrow"
+	case EmptySlot:
+		return "empty slot"
+	case OffBoard:
+		return "off the board"
+	case EmptySlotsLoop:
+		return "loop"
+	default:
+		return "unknown"
+	}
+}
+
+// NewBoardFlow works out the control flow of the circuit board.
+func NewBoardFlow(b *CircuitBoard) *BoardFlow {
+	f := &BoardFlow{chipsPos: map[Position]*FlowChip{}}
+	for y := 0; y < b.height; y++ {
+		for x := 0; x < b.width; x++ {
+			chip := b.ChipAt(x, y)
+			if chip.Type() == NoChip {
+				continue
+			}
+			fc := &FlowChip{Pos: Position{x, y}, Type: chip.Type()}
+			fc.Branches = append(fc.Branches, b.followBranch(fc.Pos, ArrowYes))
+			if chip.IsTest() {
+				fc.Branches = append(fc.Branches, b.followBranch(fc.Pos, ArrowNo))
+			}
+			f.Chips = append(f.Chips, fc)
+			f.chipsPos[fc.Pos] = fc
+		}
+	}
+	if pos, ok := b.StartPos(); ok {
+		f.start = f.chipsPos[pos]
+		f.markReachable(f.start)
+	}
+	return f
+}
+
+// Start returns the start chip, or nil if there is none.
+func (f *BoardFlow) Start() *FlowChip {
+	return f.start
+}
+
+// ChipAt returns the chip at pos, or nil if the slot is empty.
+func (f *BoardFlow) ChipAt(pos Position) *FlowChip {
+	return f.chipsPos[pos]
+}
+
+func (f *BoardFlow) markReachable(c *FlowChip) {
+	if c == nil || c.Reachable {
+		return
+	}
+	c.Reachable = true
+	for _, br := range c.Branches {
+		if br.End == ToChip {
+			f.markReachable(f.chipsPos[br.To])
+		}
+	}
+}
+
+// followBranch follows the arrow of type t of the chip at pos through empty
+// slots until it gets to a chip or the flow stops.
+func (b *CircuitBoard) followBranch(pos Position, t ArrowType) FlowBranch {
+	br := FlowBranch{Arrow: t}
+	o, ok := b.ChipAt(pos.X, pos.Y).Arrow(t == ArrowYes)
+	if !ok {
+		br.End = MissingArrow
+		return br
+	}
+	visited := map[Position]bool{}
+	for {
+		pos = pos.Move(o.VelocityForward())
+		switch {
+		case !b.Contains(pos.X, pos.Y):
+			br.End = OffBoard
+			return br
+		case b.ChipAt(pos.X, pos.Y).Type() != NoChip:
+			br.End = ToChip
+			br.To = pos
+			return br
+		case visited[pos]:
+			br.End = EmptySlotsLoop
+			return br
+		}
+		visited[pos] = true
+		br.Slots = append(br.Slots, pos)
+		if o, ok = b.ChipAt(pos.X, pos.Y).ArrowYes(); !ok {
+			br.End = EmptySlot
+			return br
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewBoardFlow(t *testing.T) {
+	tests := []struct {
+		name          string
+		board         string
+		wantChips     []Position
+		wantBranches  map[Position][]FlowBranch
+		wantReachable []Position
+	}{
+		{
+			name:          "Forward loop",
+			board:         forwardLoopBoard,
+			wantChips:     []Position{{0, 0}, {1, 0}},
+			wantReachable: []Position{{0, 0}, {1, 0}},
+			wantBranches: map[Position][]FlowBranch{
+				{0, 0}: {{Arrow: ArrowYes, End: ToChip, To: Position{1, 0}}},
+				{1, 0}: {{Arrow: ArrowYes, End: ToChip, To: Position{0, 0}, Slots: []Position{{1, 1}, {0, 1}}}},
+			},
+		},
+		{
+			name: "Decision",
+			board: `
+|ST -> W? n> TL|
+|      yv      |
+|TR    ..      |`,
+			wantChips:     []Position{{0, 0}, {1, 0}, {2, 0}, {0, 1}},
+			wantReachable: []Position{{0, 0}, {1, 0}, {2, 0}},
+			wantBranches: map[Position][]FlowBranch{
+				{1, 0}: {
+					{Arrow: ArrowYes, End: EmptySlot, Slots: []Position{{1, 1}}},
+					{Arrow: ArrowNo, End: ToChip, To: Position{2, 0}},
+				},
+				{2, 0}: {{Arrow: ArrowYes, End: MissingArrow}},
+				{0, 1}: {{Arrow: ArrowYes, End: MissingArrow}},
+			},
+		},
+		{
+			name: "Empty slots loop",
+			board: `
+|ST -> .. -> ..|
+|       ^     v|
+|      .. <- ..|`,
+			wantChips:     []Position{{0, 0}},
+			wantReachable: []Position{{0, 0}},
+			wantBranches: map[Position][]FlowBranch{
+				{0, 0}: {{Arrow: ArrowYes, End: EmptySlotsLoop, Slots: []Position{{1, 0}, {2, 0}, {2, 1}, {1, 1}}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := CircuitBoardFromString(tt.board)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f := NewBoardFlow(b)
+			var chips, reachable []Position
+			for _, c := range f.Chips {
+				chips = append(chips, c.Pos)
+				if c.Reachable {
+					reachable = append(reachable, c.Pos)
+				}
+				if want, ok := tt.wantBranches[c.Pos]; ok && !reflect.DeepEqual(c.Branches, want) {
+					t.Errorf("chip at %s: got branches %+v, want %+v", c.Pos, c.Branches, want)
+				}
+			}
+			if !reflect.DeepEqual(chips, tt.wantChips) {
+				t.Errorf("got chips %v, want %v", chips, tt.wantChips)
+			}
+			if !reflect.DeepEqual(reachable, tt.wantReachable) {
+				t.Errorf("got reachable chips %v, want %v", reachable, tt.wantReachable)
+			}
+		})
+	}
+}
+
+func TestNewBoardFlow_OffBoard(t *testing.T) {
+	// SetChipAt cannot make arrows that point off the board
+	b := &CircuitBoard{
+		width:       2,
+		height:      1,
+		chips:       []Chip{Chip(StartChip).WithArrowYes(East), Chip(ForwardChip).WithArrowYes(East)},
+		hasStartPos: true,
+	}
+	f := NewBoardFlow(b)
+	if f.Start() == nil || f.Start().Pos != (Position{0, 0}) {
+		t.Fatalf("wrong start chip %+v", f.Start())
+	}
+	want := []FlowBranch{{Arrow: ArrowYes, End: OffBoard}}
+	if got := f.ChipAt(Position{1, 0}).Branches; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
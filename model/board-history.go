@@ -0,0 +1,81 @@
+package model
+
+// Maximum number of edits that can be undone.
+const maxBoardHistory = 100
+
+// A BoardHistory records the edits made to a circuit board so they can be
+// undone and redone.  Changes to the board are recorded when Commit is called,
+// so all the changes made between two calls are undone in one step.
+type BoardHistory struct {
+	board   *CircuitBoard
+	current *CircuitBoard // The board as of the last commit
+	undo    []*CircuitBoard
+	redo    []*CircuitBoard
+}
+
+// NewBoardHistory returns a history of the edits made to b from now on.
+func NewBoardHistory(b *CircuitBoard) *BoardHistory {
+	return &BoardHistory{board: b, current: snapshot(b)}
+}
+
+// Commit records the changes made to the board since the last commit as one
+// edit, if there are any.  It returns true if there were changes.
+func (h *BoardHistory) Commit() bool {
+	if h.board.Equal(h.current) {
+		return false
+	}
+	h.undo = append(h.undo, h.current)
+	if len(h.undo) > maxBoardHistory {
+		h.undo = h.undo[1:]
+	}
+	h.redo = nil
+	h.current = snapshot(h.board)
+	return true
+}
+
+// CanUndo returns true if there is an edit to undo.
+func (h *BoardHistory) CanUndo() bool {
+	return len(h.undo) > 0 || !h.board.Equal(h.current)
+}
+
+// CanRedo returns true if there is an undone edit to redo.
+func (h *BoardHistory) CanRedo() bool {
+	return len(h.redo) > 0 && h.board.Equal(h.current)
+}
+
+// Undo restores the board as it was before the last edit.  Uncommitted
+// changes are committed first.  It returns false if there is nothing to undo.
+func (h *BoardHistory) Undo() bool {
+	h.Commit()
+	if len(h.undo) == 0 {
+		return false
+	}
+	h.redo = append(h.redo, h.current)
+	h.current = h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	h.restore()
+	return true
+}
+
+// Redo restores the board as it was before the last undo.  It returns false
+// if there is nothing to redo, which is the case after any new edit.
+func (h *BoardHistory) Redo() bool {
+	if h.Commit() || len(h.redo) == 0 {
+		return false
+	}
+	h.undo = append(h.undo, h.current)
+	h.current = h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	h.restore()
+	return true
+}
+
+func (h *BoardHistory) restore() {
+	*h.board = *h.current.Clone()
+}
+
+func snapshot(b *CircuitBoard) *CircuitBoard {
+	s := b.Clone()
+	s.ClearActiveChips()
+	return s
+}
@@ -0,0 +1,60 @@
+package model
+
+import "testing"
+
+func TestBoardHistory(t *testing.T) {
+	b, err := CircuitBoardFromString(`|ST -> MF    ..|`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewBoardHistory(b)
+	check := func(want string) {
+		t.Helper()
+		if got := b.String(); got != want+"\n" {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+	if h.CanUndo() || h.CanRedo() || h.Undo() || h.Redo() {
+		t.Error("expected nothing to undo or redo")
+	}
+
+	// Two changes committed together are one edit
+	b.SetChipAt(1, 0, b.ChipAt(1, 0).WithArrowYes(East))
+	b.SetChipAt(2, 0, b.ChipAt(2, 0).WithType(TurnLeftChip))
+	if !h.Commit() || h.Commit() {
+		t.Error("expected one commit with changes")
+	}
+	b.Reset()
+
+	// Undo commits the reset first
+	if !h.Undo() {
+		t.Fatal("undo failed")
+	}
+	check(`|ST -> MF -> TL|`)
+	if !h.Undo() {
+		t.Fatal("undo failed")
+	}
+	check(`|ST -> MF    ..|`)
+	if h.Undo() {
+		t.Error("expected nothing to undo")
+	}
+	if !h.Redo() {
+		t.Fatal("redo failed")
+	}
+	check(`|ST -> MF -> TL|`)
+
+	// A new edit drops what could be redone
+	b.ActivateChip(0, 0, East)
+	if !h.CanUndo() || !h.CanRedo() {
+		t.Error("active chips should not count as edits")
+	}
+	b.SetChipAt(2, 0, 0)
+	if h.Redo() {
+		t.Error("expected nothing to redo")
+	}
+	check(`|ST -> MF -> ..|`)
+	if !h.Undo() {
+		t.Fatal("undo failed")
+	}
+	check(`|ST -> MF -> TL|`)
+}
@@ -0,0 +1,188 @@
+package model
+
+// A Region is a rectangle of slots on a circuit board, from Min included to
+// Max excluded.
+type Region struct {
+	Min, Max Position
+}
+
+// RegionBetween returns the smallest region containing the slots p and q.
+func RegionBetween(p, q Position) Region {
+	if p.X > q.X {
+		p.X, q.X = q.X, p.X
+	}
+	if p.Y > q.Y {
+		p.Y, q.Y = q.Y, p.Y
+	}
+	return Region{Min: p, Max: Position{X: q.X + 1, Y: q.Y + 1}}
+}
+
+// Size returns the width and height of the region.
+func (r Region) Size() (int, int) {
+	return r.Max.X - r.Min.X, r.Max.Y - r.Min.Y
+}
+
+// Empty returns true if the region contains no slots.
+func (r Region) Empty() bool {
+	return r.Min.X >= r.Max.X || r.Min.Y >= r.Max.Y
+}
+
+// Contains returns true if the slot p is in the region.
+func (r Region) Contains(p Position) bool {
+	return p.X >= r.Min.X && p.X < r.Max.X && p.Y >= r.Min.Y && p.Y < r.Max.Y
+}
+
+// Translate returns the region moved by v.
+func (r Region) Translate(v Velocity) Region {
+	return Region{Min: r.Min.Move(v), Max: r.Max.Move(v)}
+}
+
+// A BoardClip is a rectangle of chips copied from a circuit board, to paste
+// elsewhere.
+type BoardClip struct {
+	width, height int
+	chips         []Chip
+}
+
+func newBoardClip(width, height int) *BoardClip {
+	return &BoardClip{
+		width:  width,
+		height: height,
+		chips:  make([]Chip, width*height),
+	}
+}
+
+// Size returns the width and height of the clip.
+func (c *BoardClip) Size() (int, int) {
+	return c.width, c.height
+}
+
+func (c *BoardClip) ChipAt(x, y int) Chip {
+	return c.chips[x+c.width*y]
+}
+
+func (c *BoardClip) setChipAt(x, y int, chip Chip) {
+	c.chips[x+c.width*y] = chip
+}
+
+// Rotate returns the clip turned a quarter clockwise, arrows included.
+func (c *BoardClip) Rotate() *BoardClip {
+	r := newBoardClip(c.height, c.width)
+	for y := 0; y < c.height; y++ {
+		for x := 0; x < c.width; x++ {
+			r.setChipAt(c.height-1-y, x, c.ChipAt(x, y).MapArrows(rotateRight))
+		}
+	}
+	return r
+}
+
+// FlipHorizontal returns the clip mirrored from left to right.
+func (c *BoardClip) FlipHorizontal() *BoardClip {
+	r := newBoardClip(c.width, c.height)
+	for y := 0; y < c.height; y++ {
+		for x := 0; x < c.width; x++ {
+			r.setChipAt(c.width-1-x, y, c.ChipAt(x, y).MapArrows(flipEastWest))
+		}
+	}
+	return r
+}
+
+// FlipVertical returns the clip mirrored from top to bottom.
+func (c *BoardClip) FlipVertical() *BoardClip {
+	r := newBoardClip(c.width, c.height)
+	for y := 0; y < c.height; y++ {
+		for x := 0; x < c.width; x++ {
+			r.setChipAt(x, c.height-1-y, c.ChipAt(x, y).MapArrows(flipNorthSouth))
+		}
+	}
+	return r
+}
+
+func rotateRight(o Orientation) Orientation {
+	return o.Rotate(Right)
+}
+
+func flipEastWest(o Orientation) Orientation {
+	if o == East || o == West {
+		return o.Reverse()
+	}
+	return o
+}
+
+func flipNorthSouth(o Orientation) Orientation {
+	if o == North || o == South {
+		return o.Reverse()
+	}
+	return o
+}
+
+// Bounds returns the region covering the whole board.
+func (b *CircuitBoard) Bounds() Region {
+	return Region{Max: Position{X: b.width, Y: b.height}}
+}
+
+// Clip returns the part of r that is on the board.
+func (b *CircuitBoard) Clip(r Region) Region {
+	if r.Min.X < 0 {
+		r.Min.X = 0
+	}
+	if r.Min.Y < 0 {
+		r.Min.Y = 0
+	}
+	if r.Max.X > b.width {
+		r.Max.X = b.width
+	}
+	if r.Max.Y > b.height {
+		r.Max.Y = b.height
+	}
+	if r.Empty() {
+		return Region{}
+	}
+	return r
+}
+
+// Copy returns the chips in the region, without the parts of it that are not
+// on the board.
+func (b *CircuitBoard) Copy(r Region) *BoardClip {
+	r = b.Clip(r)
+	w, h := r.Size()
+	c := newBoardClip(w, h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c.setChipAt(x, y, b.ChipAt(r.Min.X+x, r.Min.Y+y).ClearActive())
+		}
+	}
+	return c
+}
+
+// ClearRegion removes the chips and arrows in the region.
+func (b *CircuitBoard) ClearRegion(r Region) {
+	r = b.Clip(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			b.SetChipAt(x, y, 0)
+		}
+	}
+}
+
+// Paste replaces the slots of the board covered by the clip, with its top
+// left corner at p.  The parts of the clip that are not on the board are
+// dropped.  It returns the region of the board the clip was pasted to.
+func (b *CircuitBoard) Paste(c *BoardClip, p Position) Region {
+	r := b.Clip(Region{Min: p, Max: Position{X: p.X + c.width, Y: p.Y + c.height}})
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			b.SetChipAt(x, y, c.ChipAt(x-p.X, y-p.Y))
+		}
+	}
+	return r
+}
+
+// Move moves the chips in the region by v, like cutting and pasting them.  It
+// returns the region they were moved to.
+func (b *CircuitBoard) Move(r Region, v Velocity) Region {
+	r = b.Clip(r)
+	c := b.Copy(r)
+	b.ClearRegion(r)
+	return b.Paste(c, r.Min.Move(v))
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCircuitBoard_Regions(t *testing.T) {
+	const board = `
+|ST -> W? n> MF|
+|      yv     v|
+|..    TL    ..|`
+	tests := []struct {
+		name   string
+		edit   func(b *CircuitBoard) Region
+		want   string
+		region Region
+	}{
+		{
+			name: "Clear",
+			edit: func(b *CircuitBoard) Region {
+				r := RegionBetween(Position{X: 2, Y: 1}, Position{X: 1, Y: 0})
+				b.ClearRegion(r)
+				return r
+			},
+			want: `
+|ST -> ..    ..|
+|              |
+|..    ..    ..|`,
+			region: Region{Min: Position{X: 1}, Max: Position{X: 3, Y: 2}},
+		},
+		{
+			name: "Move",
+			edit: func(b *CircuitBoard) Region {
+				return b.Move(RegionBetween(Position{X: 1}, Position{X: 1, Y: 1}), Velocity{Dx: 1})
+			},
+			want: `
+|ST -> ..    W?|
+|            yv|
+|..    ..    TL|`,
+			region: Region{Min: Position{X: 2}, Max: Position{X: 3, Y: 2}},
+		},
+		{
+			name: "Paste off the board",
+			edit: func(b *CircuitBoard) Region {
+				return b.Paste(b.Copy(RegionBetween(Position{}, Position{X: 1})), Position{X: 2, Y: 1})
+			},
+			want: `
+|.. -> W? n> MF|
+|      yv     v|
+|..    TL    ST|`,
+			region: Region{Min: Position{X: 2, Y: 1}, Max: Position{X: 3, Y: 2}},
+		},
+		{
+			name: "Rotate",
+			edit: func(b *CircuitBoard) Region {
+				r := RegionBetween(Position{}, Position{X: 1, Y: 1})
+				c := b.Copy(r)
+				b.ClearRegion(r)
+				return b.Paste(c.Rotate(), r.Min)
+			},
+			want: `
+|..    ST    MF|
+|       v     v|
+|TL <y W?    ..|`,
+			region: Region{Max: Position{X: 2, Y: 2}},
+		},
+		{
+			name: "Flip",
+			edit: func(b *CircuitBoard) Region {
+				r := b.Bounds()
+				c := b.Copy(r)
+				return b.Paste(c.FlipHorizontal().FlipVertical(), r.Min)
+			},
+			want: `
+|..    TL    ..|
+| ^    y^      |
+|MF <n W? <- ST|`,
+			region: Region{Max: Position{X: 3, Y: 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := CircuitBoardFromString(board)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := tt.edit(b)
+			want := strings.TrimPrefix(tt.want, "\n") + "\n"
+			if got := b.String(); got != want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+			if r != tt.region {
+				t.Errorf("got region %v, want %v", r, tt.region)
+			}
+		})
+	}
+}
+
+func TestCircuitBoard_CopyClipsRegion(t *testing.T) {
+	b := NewCircuitBoard(3, 2)
+	c := b.Copy(Region{Min: Position{X: -1, Y: 1}, Max: Position{X: 5, Y: 5}})
+	if w, h := c.Size(); w != 3 || h != 1 {
+		t.Errorf("got size %dx%d, want 3x1", w, h)
+	}
+	if r := b.Clip(Region{Min: Position{X: 4}, Max: Position{X: 6, Y: 1}}); !r.Empty() {
+		t.Errorf("got region %v, want an empty region", r)
+	}
+}
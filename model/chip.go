@@ -77,6 +77,19 @@ func (c Chip) WithArrow(o Orientation, a ArrowType) Chip {
 	}
 }
 
+// MapArrows returns the chip with the orientation of its arrows changed by
+// f, e.g. to rotate it.  The chip is no longer active.
+func (c Chip) MapArrows(f func(Orientation) Orientation) Chip {
+	m := c.ClearActive().ClearArrowYes().ClearArrowNo()
+	if o, ok := c.ArrowYes(); ok {
+		m |= Chip((0x4 | f(o)) << 8)
+	}
+	if o, ok := c.ArrowNo(); ok {
+		m |= Chip((0x4 | f(o)) << 12)
+	}
+	return m
+}
+
 func (c Chip) IsActive() bool {
 	return (c & 0xf0) != 0
 }
@@ -136,6 +149,37 @@ const (
 	IsFloorBlueChip
 )
 
+func (t ChipType) String() string {
+	switch t {
+	case NoChip:
+		return "empty"
+	case StartChip:
+		return "start"
+	case ForwardChip:
+		return "move forward"
+	case TurnLeftChip:
+		return "turn left"
+	case TurnRightChip:
+		return "turn right"
+	case PaintRedChip:
+		return "paint red"
+	case PaintYellowChip:
+		return "paint yellow"
+	case PaintBlueChip:
+		return "paint blue"
+	case IsWallAheadChip:
+		return "wall ahead?"
+	case IsFloorRedChip:
+		return "floor red?"
+	case IsFloorYellowChip:
+		return "floor yellow?"
+	case IsFloorBlueChip:
+		return "floor blue?"
+	default:
+		return "unknown"
+	}
+}
+
 func (t ChipType) IsDecision() bool {
 	return t >= IsWallAheadChip
 }
@@ -147,3 +191,16 @@ const (
 	ArrowYes
 	ArrowNo
 )
+
+func (a ArrowType) String() string {
+	switch a {
+	case NoArrow:
+		return "none"
+	case ArrowYes:
+		return "yes"
+	case ArrowNo:
+		return "no"
+	default:
+		return "unknown"
+	}
+}
@@ -47,31 +47,34 @@ func CircuitBoardFromString(s string) (*CircuitBoard, error) {
 	}
 	b := NewCircuitBoard(width, height)
 	b.hasStartPos = false
+
+	// Set the chips first, as arrows of decision chips can only be set once
+	// the chip type is known.
+	for i := 0; i < len(rows); i += 2 {
+		row, y := rows[i], i/2
+		for x := 0; x < width; x++ {
+			chipCode := row[x*6 : x*6+2]
+			chipType, ok := chipTypeMap[chipCode]
+			if !ok {
+				return nil, fmt.Errorf("invalid chip code at line %d, column %d: %q", i+1, x*6+1, chipCode)
+			}
+			if chipType == StartChip {
+				if b.hasStartPos {
+					return nil, fmt.Errorf("Only one start chip allowed: found second at line %d, column %d", i+1, x*6+1)
+				}
+				b.startPos = Position{X: x, Y: y}
+				b.hasStartPos = true
+			}
+			b.SetChipAt(x, y, b.ChipAt(x, y).WithType(chipType))
+		}
+	}
+
+	// Then set the arrows
 	for i, row := range rows {
 		y := i / 2
 		if i%2 == 0 {
 			// This is a row of chips
-			for x := 0; x < width; x++ {
-
-				// Set the chip
-				chipCode := row[x*6 : x*6+2]
-				chipType, ok := chipTypeMap[chipCode]
-				if !ok {
-					return nil, fmt.Errorf("invalid chip code at line %d, column %d: %q", i+1, x*6+1, chipCode)
-				}
-				if chipType == StartChip {
-					if b.hasStartPos {
-						return nil, fmt.Errorf("Only one start chip allowed: found second at line %d, column %d", i+1, x*6+1)
-					}
-					b.startPos = Position{X: x, Y: y}
-					b.hasStartPos = true
-				}
-				b.SetChipAt(x, y, b.ChipAt(x, y).WithType(chipType))
-				if x == width-1 {
-					continue
-				}
-
-				// Set the arrow
+			for x := 0; x < width-1; x++ {
 				switch arrCode := row[x*6+3 : x*6+5]; arrCode {
 				case "y>":
 					b.SetChipAt(x, y, b.ChipAt(x, y).WithArrowYes(East))
@@ -121,6 +124,123 @@ func CircuitBoardFromString(s string) (*CircuitBoard, error) {
 	return b, nil
 }
 
+// String returns the board in the format understood by
+// CircuitBoardFromString.  Arrows pointing off the board are not shown.
+func (b *CircuitBoard) String() string {
+	return b.write(false)
+}
+
+// write returns the board in the format of String.  If showActive is true,
+// active chips have lower case codes ("::" for empty slots) and active arrows
+// are drawn with '=', 'Y' or 'N' instead of '-', 'y' or 'n'.
+func (b *CircuitBoard) write(showActive bool) string {
+	var sb strings.Builder
+	for y := 0; y < b.height; y++ {
+		sb.WriteByte('|')
+		for x := 0; x < b.width; x++ {
+			chip := b.ChipAt(x, y)
+			sb.WriteString(chipCode(chip, showActive))
+			if x == b.width-1 {
+				break
+			}
+			next := b.ChipAt(x+1, y)
+			sb.WriteByte(' ')
+			if a := arrowCode(chip, East, showActive); a != 0 {
+				sb.WriteByte(a)
+				sb.WriteByte('>')
+			} else if a := arrowCode(next, West, showActive); a != 0 {
+				sb.WriteByte('<')
+				sb.WriteByte(a)
+			} else {
+				sb.WriteString("  ")
+			}
+			sb.WriteByte(' ')
+		}
+		sb.WriteString("|\n")
+		if y == b.height-1 {
+			break
+		}
+		sb.WriteByte('|')
+		for x := 0; x < b.width; x++ {
+			if x > 0 {
+				sb.WriteString("    ")
+			}
+			if a := arrowCode(b.ChipAt(x, y), South, showActive); a != 0 {
+				sb.WriteByte(verticalArrowCode(a))
+				sb.WriteByte('v')
+			} else if a := arrowCode(b.ChipAt(x, y+1), North, showActive); a != 0 {
+				sb.WriteByte(verticalArrowCode(a))
+				sb.WriteByte('^')
+			} else {
+				sb.WriteString("  ")
+			}
+		}
+		sb.WriteString("|\n")
+	}
+	return sb.String()
+}
+
+func chipCode(c Chip, showActive bool) string {
+	code := chipTypeCodes[c.Type()]
+	if !showActive || !c.IsActive() {
+		return code
+	}
+	if c.Type() == NoChip {
+		return "::"
+	}
+	return strings.ToLower(code)
+}
+
+// arrowCode returns the character used to represent the arrow of a chip in
+// direction o: '-' for plain arrows, 'y' or 'n' for decision chips and 0 if
+// there is no arrow.  If showActive is true, active arrows are '=', 'Y' or
+// 'N'.
+func arrowCode(c Chip, o Orientation, showActive bool) byte {
+	var a byte
+	if oy, ok := c.ArrowYes(); ok && oy == o {
+		a = '-'
+		if c.IsTest() {
+			a = 'y'
+		}
+	} else if on, ok := c.ArrowNo(); ok && on == o && c.IsTest() {
+		a = 'n'
+	}
+	if a != 0 && showActive && c.IsArrowActive(o) {
+		return activeArrowCodes[a]
+	}
+	return a
+}
+
+var activeArrowCodes = map[byte]byte{'-': '=', 'y': 'Y', 'n': 'N'}
+
+func verticalArrowCode(a byte) byte {
+	if a == '-' {
+		return ' '
+	}
+	return a
+}
+
+func (b *CircuitBoard) Clone() *CircuitBoard {
+	clone := *b
+	clone.chips = make([]Chip, len(b.chips))
+	copy(clone.chips, b.chips)
+	return &clone
+}
+
+// Equal returns true if the boards have the same chips and arrows.  Active
+// chips are not taken into account.
+func (b *CircuitBoard) Equal(other *CircuitBoard) bool {
+	if b.width != other.width || b.height != other.height {
+		return false
+	}
+	for i, c := range b.chips {
+		if c.ClearActive() != other.chips[i].ClearActive() {
+			return false
+		}
+	}
+	return true
+}
+
 func (b *CircuitBoard) Size() (int, int) {
 	return b.width, b.height
 }
@@ -188,6 +308,9 @@ func (b *CircuitBoard) ActivateChip(x, y int, o Orientation) {
 }
 
 func (b *CircuitBoard) deleteArrow(p Position, o Orientation) {
+	if !b.Contains(p.X, p.Y) {
+		return
+	}
 	b.chips[b.chipIndex(p.X, p.Y)] = b.ChipAt(p.X, p.Y).ClearArrow(o)
 }
 
@@ -210,3 +333,18 @@ var chipTypeMap = map[string]ChipType{
 	"..": NoChip,
 	"  ": NoChip,
 }
+
+var chipTypeCodes = map[ChipType]string{
+	NoChip:            "..",
+	StartChip:         "ST",
+	IsWallAheadChip:   "W?",
+	IsFloorBlueChip:   "B?",
+	IsFloorRedChip:    "R?",
+	IsFloorYellowChip: "Y?",
+	ForwardChip:       "MF",
+	TurnLeftChip:      "TL",
+	TurnRightChip:     "TR",
+	PaintBlueChip:     "PB",
+	PaintRedChip:      "PR",
+	PaintYellowChip:   "PY",
+}
@@ -22,10 +22,11 @@ func TestCircuitBoardFromString(t *testing.T) {
 				s: "|ST|",
 			},
 			want: &CircuitBoard{
-				width:    1,
-				height:   1,
-				chips:    []Chip{Chip(StartChip)},
-				startPos: Position{X: 0, Y: 0},
+				width:       1,
+				height:      1,
+				chips:       []Chip{Chip(StartChip)},
+				startPos:    Position{X: 0, Y: 0},
+				hasStartPos: true,
 			},
 		},
 		{
@@ -34,10 +35,11 @@ func TestCircuitBoardFromString(t *testing.T) {
 				s: "|ST -> MF|",
 			},
 			want: &CircuitBoard{
-				width:    2,
-				height:   1,
-				chips:    []Chip{Chip(StartChip).WithArrowYes(East), Chip(ForwardChip)},
-				startPos: Position{X: 0, Y: 0},
+				width:       2,
+				height:      1,
+				chips:       []Chip{Chip(StartChip).WithArrowYes(East), Chip(ForwardChip)},
+				startPos:    Position{X: 0, Y: 0},
+				hasStartPos: true,
 			},
 		},
 		{
@@ -49,10 +51,11 @@ func TestCircuitBoardFromString(t *testing.T) {
 |ST|`,
 			},
 			want: &CircuitBoard{
-				width:    1,
-				height:   2,
-				chips:    []Chip{Chip(TurnLeftChip), Chip(StartChip).WithArrowYes(North)},
-				startPos: Position{X: 0, Y: 1},
+				width:       1,
+				height:      2,
+				chips:       []Chip{Chip(TurnLeftChip), Chip(StartChip).WithArrowYes(North)},
+				startPos:    Position{X: 0, Y: 1},
+				hasStartPos: true,
 			},
 		},
 		{
@@ -77,7 +80,8 @@ func TestCircuitBoardFromString(t *testing.T) {
 					Chip(ForwardChip),
 					Chip(NoChip).WithArrowYes(West),
 				},
-				startPos: Position{X: 0, Y: 0},
+				startPos:    Position{X: 0, Y: 0},
+				hasStartPos: true,
 			},
 		},
 		// TODO: Add sad path test cases.
@@ -95,3 +99,40 @@ func TestCircuitBoardFromString(t *testing.T) {
 		})
 	}
 }
+
+func TestCircuitBoard_String(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+	}{
+		{
+			name: "Simplest",
+			s:    "|ST|\n",
+		},
+		{
+			name: "3x2 with yes and no",
+			s: `|ST -> W? y> TL|
+|      nv     v|
+|.. <- MF <- ..|
+`,
+		},
+		{
+			name: "Arrows up and left",
+			s: `|TR <n R? <- ST|
+| ^    y^      |
+|PB <n B? <- TL|
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := CircuitBoardFromString(tt.s)
+			if err != nil {
+				t.Fatalf("CircuitBoardFromString() error = %v", err)
+			}
+			if got := b.String(); got != tt.s {
+				t.Errorf("CircuitBoard.String() = %q, want %q", got, tt.s)
+			}
+		})
+	}
+}
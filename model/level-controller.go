@@ -45,6 +45,8 @@ type LevelController struct {
 	boardPos Position
 	score    int
 	deadEnd  bool
+	command  Command
+	crashed  bool
 }
 
 func NewLevelController(level *Level, board *CircuitBoard) *LevelController {
@@ -71,6 +73,11 @@ func (c *LevelController) GameWon() bool {
 	return c.maze.FlagsRemaining() == 0
 }
 
+// DeadEnd returns true if the circuit board cannot produce any more commands.
+func (c *LevelController) DeadEnd() bool {
+	return c.deadEnd
+}
+
 func (c *LevelController) Score() int {
 	return c.score
 }
@@ -81,9 +88,32 @@ func (c *LevelController) Advance() {
 		log.Printf("Level Cleared!")
 		c.board.ClearActiveChips()
 		c.maze.StopRobot()
+		c.command, c.crashed = NoCommand, false
 		return
 	}
-	c.maze.CommandRobot(c.NextCommand())
+	c.command = c.NextCommand()
+	c.crashed = !c.maze.CommandRobot(c.command)
+}
+
+// cancelCommand stops the robot before it carries out the command given by
+// the last call to Advance, which is then not paid for.
+func (c *LevelController) cancelCommand() {
+	if c.command != NoCommand {
+		c.score -= c.level.MoveCost
+	}
+	c.maze.StopRobot()
+}
+
+// Command returns the command given to the robot by the last call to
+// Advance.
+func (c *LevelController) Command() Command {
+	return c.command
+}
+
+// Crashed returns true if the last command given to the robot was to move
+// forward into a wall, in which case the robot stays where it is.
+func (c *LevelController) Crashed() bool {
+	return c.crashed
 }
 
 func (c *LevelController) NextCommand() Command {
@@ -105,10 +135,11 @@ func (c *LevelController) NextCommand() Command {
 			nextChipDir, ok = chip.Arrow(arrowType)
 		)
 		c.board.ActivateChip(c.boardPos.X, c.boardPos.Y, nextChipDir)
-		if ok {
-			c.boardPos = c.boardPos.Move(nextChipDir.VelocityForward())
+		if next := c.boardPos.Move(nextChipDir.VelocityForward()); ok && c.board.Contains(next.X, next.Y) {
+			c.boardPos = next
 			c.deadEnd = com == NoCommand && c.board.ChipAt(c.boardPos.X, c.boardPos.Y).IsActive()
 		} else {
+			// There is no arrow or it points off the board
 			c.deadEnd = true
 		}
 		log.Printf("Board -> %s, com: %s", c.boardPos, com)
@@ -0,0 +1,149 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	errMazeMissing = errors.New("Maze definition is missing")
+	errRobotOnFlag = errors.New("the robot cannot start on a flag in .r2f format")
+)
+
+// A LevelFormat is a file format that levels can be read from and written to.
+type LevelFormat struct {
+	Name string
+	Ext  string // File extension, including the leading "."
+
+	// Decode parses a level.  The name of the level is defaultName unless
+	// the data specifies it.
+	Decode func(defaultName string, data []byte) (*Level, error)
+
+	// Encode is the inverse of Decode.
+	Encode func(l *Level, defaultName string) ([]byte, error)
+}
+
+var R2FLevelFormat = LevelFormat{
+	Name: "r2f",
+	Ext:  ".r2f",
+	Decode: func(defaultName string, data []byte) (*Level, error) {
+		return LevelFromString(defaultName, string(data))
+	},
+	Encode: func(l *Level, defaultName string) ([]byte, error) {
+		if err := checkR2FCompatible(l.Maze); err != nil {
+			return nil, err
+		}
+		return []byte(LevelToString(l, defaultName)), nil
+	},
+}
+
+var JSONLevelFormat = LevelFormat{
+	Name:   "json",
+	Ext:    ".json",
+	Decode: LevelFromJSON,
+	Encode: func(l *Level, defaultName string) ([]byte, error) {
+		return LevelToJSON(l)
+	},
+}
+
+// LevelFormats lists all supported level formats.
+var LevelFormats = []LevelFormat{R2FLevelFormat, JSONLevelFormat}
+
+// LevelFormatByName returns the level format with the given name.
+func LevelFormatByName(name string) (LevelFormat, bool) {
+	for _, f := range LevelFormats {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return LevelFormat{}, false
+}
+
+// LevelFormatForFile returns the level format to use for a file, based on
+// its extension.
+func LevelFormatForFile(filename string) (LevelFormat, bool) {
+	ext := strings.ToLower(path.Ext(filename))
+	for _, f := range LevelFormats {
+		if f.Ext == ext {
+			return f, true
+		}
+	}
+	return LevelFormat{}, false
+}
+
+// FormatLevel rewrites a level in the canonical form of its format, e.g. to
+// tidy up a level file.  Unknown keys of a .r2f level are kept at the end.
+// Unknown fields of a JSON level cannot be kept, so they are an error.
+func FormatLevel(format LevelFormat, defaultName string, data []byte) ([]byte, error) {
+	switch format.Name {
+	case R2FLevelFormat.Name:
+		s, err := formatLevelString(defaultName, string(data))
+		return []byte(s), err
+	}
+	l, err := format.Decode(defaultName, data)
+	if err != nil {
+		return nil, err
+	}
+	if format.Name == JSONLevelFormat.Name {
+		if err := checkJSONFields(data); err != nil {
+			return nil, fmt.Errorf("%s would be lost", strings.TrimPrefix(err.Error(), "json: "))
+		}
+	}
+	return format.Encode(l, defaultName)
+}
+
+// A LevelFileError is an error in the content of a level file.
+type LevelFileError struct {
+	Filename string
+	Err      error
+}
+
+func (e *LevelFileError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Filename, e.Err)
+}
+
+func (e *LevelFileError) Unwrap() error {
+	return e.Err
+}
+
+// LevelNameForFile returns the default name of the level in a file, which is
+// the base name of the file without its extension.
+func LevelNameForFile(filename string) string {
+	base := filepath.Base(filename)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// ReadLevelFile reads a level file, choosing the format from the file
+// extension.  The level is named after the file unless the file gives its
+// name.  Errors in the content of the file have type *LevelFileError.
+func ReadLevelFile(filename string) (*Level, error) {
+	format, ok := LevelFormatForFile(filename)
+	if !ok {
+		return nil, &LevelFileError{filename, errors.New("unknown level format")}
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	level, err := format.Decode(LevelNameForFile(filename), data)
+	if err != nil {
+		return nil, &LevelFileError{filename, err}
+	}
+	return level, nil
+}
+
+// checkR2FCompatible returns an error if the maze cannot be written to a .r2f
+// file without losing information.
+func checkR2FCompatible(m *Maze) error {
+	if m == nil {
+		return errMazeMissing
+	}
+	if r := m.robot; r != nil && m.CellAt(r.X, r.Y).Flag() {
+		return errRobotOnFlag
+	}
+	return nil
+}
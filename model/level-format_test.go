@@ -0,0 +1,179 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testLevel = `
++--+--+--+--+
+|RF|R |R  RF|
++  .  .  .  +
+|Y  B> Y  B |
++--+--+  +  +
+|BF Y  B |YF|
++--+--+--+--+
+Name: Test level
+ChipCost: 5
+`
+
+func TestLevelFormat_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   LevelFormat
+		to     LevelFormat
+		source string
+	}{
+		{
+			name:   "r2f to json",
+			from:   R2FLevelFormat,
+			to:     JSONLevelFormat,
+			source: testLevel,
+		},
+		{
+			name:   "r2f to r2f",
+			from:   R2FLevelFormat,
+			to:     R2FLevelFormat,
+			source: testLevel,
+		},
+		{
+			name: "json to r2f",
+			from: JSONLevelFormat,
+			to:   R2FLevelFormat,
+			source: `{
+  "version": 1,
+  "boardWidth": 4,
+  "maze": {
+    "width": 2,
+    "height": 1,
+    "robot": {"x": 0, "y": 0, "facing": "east"},
+    "cells": [
+      [{"northWall": true, "westWall": true, "corner": true, "color": "red"}, {"northWall": true, "corner": true, "flag": true}]
+    ]
+  },
+  "someFutureField": "ignored"
+}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := tt.from.Decode("test", []byte(tt.source))
+			if err != nil {
+				t.Fatalf("%s.Decode() error = %v", tt.from.Name, err)
+			}
+			data, err := tt.to.Encode(want, "test")
+			if err != nil {
+				t.Fatalf("%s.Encode() error = %v", tt.to.Name, err)
+			}
+			got, err := tt.to.Decode("test", data)
+			if err != nil {
+				t.Fatalf("%s.Decode() error = %v\n%s", tt.to.Name, err, data)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip through %s: got %+v, want %+v", tt.to.Name, got, want)
+			}
+		})
+	}
+}
+
+func TestLevelFormatForFile(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     string
+		wantOk   bool
+	}{
+		{filename: "levels/one.r2f", want: "r2f", wantOk: true},
+		{filename: "one.JSON", want: "json", wantOk: true},
+		{filename: "README.md", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			got, ok := LevelFormatForFile(tt.filename)
+			if ok != tt.wantOk || got.Name != tt.want {
+				t.Errorf("LevelFormatForFile() = %q, %t, want %q, %t", got.Name, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestFormatLevel(t *testing.T) {
+	const maze = `+--+--+
+|R> RF|
++--+--+
+`
+	tests := []struct {
+		name    string
+		format  LevelFormat
+		source  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "Unknown keys are kept",
+			format: R2FLevelFormat,
+			source: maze + "Author:bob\nHint:  go\n  right\nChipCost: 5\nNotes:\n",
+			want:   maze + "ChipCost: 5\nAuthor: bob\nHint: go\n  right\nNotes:\n",
+		},
+		{
+			name:   "Canonical",
+			format: R2FLevelFormat,
+			source: maze + "Name: test\n",
+			want:   maze,
+		},
+		{
+			name:    "Unknown JSON fields",
+			format:  JSONLevelFormat,
+			source:  `{"version": 1, "Author": "bob", "maze": {"width": 1, "height": 1, "cells": [[{}]]}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Unknown JSON field in a cell",
+			format:  JSONLevelFormat,
+			source:  `{"version": 1, "maze": {"width": 1, "height": 1, "cells": [[{"flag": true, "note": "x"}]]}}`,
+			wantErr: true,
+		},
+		{
+			name:   "Known JSON fields in any case",
+			format: JSONLevelFormat,
+			source: `{"Version": 1, "MAZE": {"width": 1, "height": 1, "cells": [[{"Flag": true}]]}}`,
+			want: `{
+  "version": 1,
+  "name": "test",
+  "boardWidth": 9,
+  "boardHeight": 9,
+  "chipCost": 10,
+  "moveCost": 1,
+  "maze": {
+    "width": 1,
+    "height": 1,
+    "cells": [
+      [{"flag":true}]
+    ]
+  }
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FormatLevel(tt.format, "test", []byte(tt.source))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got\n%s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+			// Formatting is idempotent
+			again, err := FormatLevel(tt.format, "test", got)
+			if err != nil || string(again) != string(got) {
+				t.Errorf("formatting again: got\n%s\n(error %v)", again, err)
+			}
+		})
+	}
+}
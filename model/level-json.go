@@ -0,0 +1,269 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Version of the JSON level format written by LevelToJSON.  Fields unknown to
+// this version are ignored when reading, so new optional fields can be added
+// without bumping the version.
+const jsonLevelVersion = 1
+
+type jsonLevel struct {
+	Version     int       `json:"version"`
+	Name        string    `json:"name,omitempty"`
+	BoardWidth  int       `json:"boardWidth"`
+	BoardHeight int       `json:"boardHeight"`
+	ChipCost    int       `json:"chipCost"`
+	MoveCost    int       `json:"moveCost"`
+	Maze        *jsonMaze `json:"maze"`
+}
+
+type jsonMaze struct {
+	Width  int          `json:"width"`
+	Height int          `json:"height"`
+	Robot  *jsonRobot   `json:"robot,omitempty"`
+	Cells  [][]jsonCell `json:"cells"`
+}
+
+type jsonRobot struct {
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Facing string `json:"facing"`
+}
+
+type jsonCell struct {
+	NorthWall bool   `json:"northWall,omitempty"`
+	WestWall  bool   `json:"westWall,omitempty"`
+	Corner    bool   `json:"corner,omitempty"`
+	Color     string `json:"color,omitempty"`
+	Flag      bool   `json:"flag,omitempty"`
+}
+
+// LevelFromJSON parses a level in JSON format.  Settings that are missing
+// take the same default values as in LevelFromString.
+func LevelFromJSON(defaultName string, data []byte) (*Level, error) {
+	lvl := defaultLevel(defaultName)
+	jl := jsonLevel{
+		Name:        lvl.Name,
+		BoardWidth:  lvl.BoardWidth,
+		BoardHeight: lvl.BoardHeigth,
+		ChipCost:    lvl.ChipCost,
+		MoveCost:    lvl.MoveCost,
+	}
+	if err := json.Unmarshal(data, &jl); err != nil {
+		return nil, jsonParseError(data, err)
+	}
+	if jl.Version > jsonLevelVersion {
+		return nil, fmt.Errorf("unsupported level format version %d", jl.Version)
+	}
+	if jl.Maze == nil {
+		return nil, errMazeMissing
+	}
+	maze, err := jl.Maze.toMaze()
+	if err != nil {
+		return nil, err
+	}
+	lvl.Name = jl.Name
+	lvl.Maze = maze
+	lvl.BoardWidth = jl.BoardWidth
+	lvl.BoardHeigth = jl.BoardHeight
+	lvl.ChipCost = jl.ChipCost
+	lvl.MoveCost = jl.MoveCost
+	return &lvl, nil
+}
+
+// checkJSONFields returns an error if a JSON level has fields, at any depth,
+// that are ignored by LevelFromJSON.  Only call it on a valid level.
+func checkJSONFields(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(&jsonLevel{})
+}
+
+// LevelToJSON returns the level in the format understood by LevelFromJSON.
+// Each row of maze cells is written on a single line to keep the output
+// readable.
+func LevelToJSON(l *Level) ([]byte, error) {
+	jm, err := mazeToJSON(l.Maze)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	b.WriteString("{\n")
+	writeJSONField(&b, "  ", "version", jsonLevelVersion)
+	writeJSONField(&b, "  ", "name", l.Name)
+	writeJSONField(&b, "  ", "boardWidth", l.BoardWidth)
+	writeJSONField(&b, "  ", "boardHeight", l.BoardHeigth)
+	writeJSONField(&b, "  ", "chipCost", l.ChipCost)
+	writeJSONField(&b, "  ", "moveCost", l.MoveCost)
+	b.WriteString(`  "maze": {` + "\n")
+	writeJSONField(&b, "    ", "width", jm.Width)
+	writeJSONField(&b, "    ", "height", jm.Height)
+	if jm.Robot != nil {
+		writeJSONField(&b, "    ", "robot", jm.Robot)
+	}
+	b.WriteString(`    "cells": [` + "\n")
+	for i, row := range jm.Cells {
+		rowData, err := json.Marshal(row)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString("      ")
+		b.Write(rowData)
+		if i < len(jm.Cells)-1 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteString("    ]\n  }\n}\n")
+	return b.Bytes(), nil
+}
+
+// jsonParseError converts errors from the json package to a *ParseError
+// pointing to where the problem is, if it is known.
+func jsonParseError(data []byte, err error) error {
+	var offset int64
+	switch jerr := err.(type) {
+	case *json.SyntaxError:
+		offset = jerr.Offset
+	case *json.UnmarshalTypeError:
+		offset = jerr.Offset
+	default:
+		return err
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := len(before) - bytes.LastIndexByte(before, '\n')
+	return &ParseError{Line: line, Col: col, Msg: err.Error()}
+}
+
+// marshalJSON is like json.Marshal but does not escape '<' and '>', which
+// are common in mazes and circuit boards.  If prefix is not empty, the output
+// is indented as with json.MarshalIndent(v, prefix, "  ").
+func marshalJSON(v interface{}, prefix string) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if prefix != "" {
+		enc.SetIndent(prefix, "  ")
+	}
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+func writeJSONField(b *bytes.Buffer, indent string, name string, value interface{}) {
+	data, err := marshalJSON(value, "")
+	if err != nil {
+		// Only called with values that can be marshalled
+		panic(err)
+	}
+	fmt.Fprintf(b, "%s%q: %s,\n", indent, name, data)
+}
+
+func mazeToJSON(m *Maze) (*jsonMaze, error) {
+	if m == nil {
+		return nil, errMazeMissing
+	}
+	jm := &jsonMaze{
+		Width:  m.width,
+		Height: m.height,
+		Cells:  make([][]jsonCell, m.height),
+	}
+	if m.robot != nil {
+		jm.Robot = &jsonRobot{
+			X:      m.robot.X,
+			Y:      m.robot.Y,
+			Facing: m.robot.Orientation.String(),
+		}
+	}
+	for y := range jm.Cells {
+		row := make([]jsonCell, m.width)
+		for x := range row {
+			cell := m.CellAt(x, y)
+			row[x] = jsonCell{
+				NorthWall: cell.NorthWall(),
+				WestWall:  cell.WestWall(),
+				Corner:    cell.CornerWall(),
+				Flag:      cell.Flag(),
+			}
+			if col := cell.Color(); col != NoColor {
+				row[x].Color = col.String()
+			}
+		}
+		jm.Cells[y] = row
+	}
+	return jm, nil
+}
+
+func (jm *jsonMaze) toMaze() (*Maze, error) {
+	if jm.Width <= 0 || jm.Height <= 0 {
+		return nil, fmt.Errorf("invalid maze size %dx%d", jm.Width, jm.Height)
+	}
+	if len(jm.Cells) != jm.Height {
+		return nil, fmt.Errorf("expected %d rows of cells, got %d", jm.Height, len(jm.Cells))
+	}
+	maze := NewMaze(jm.Width, jm.Height)
+	for y, row := range jm.Cells {
+		if len(row) != jm.Width {
+			return nil, fmt.Errorf("expected %d cells in row %d, got %d", jm.Width, y, len(row))
+		}
+		for x, jc := range row {
+			var cell Cell
+			if jc.NorthWall {
+				cell |= TF
+			}
+			if jc.WestWall {
+				cell |= LF
+			}
+			if jc.Corner {
+				cell |= CF
+			}
+			if jc.Flag {
+				cell |= FF
+			}
+			if jc.Color != "" {
+				col, ok := name2Color[jc.Color]
+				if !ok {
+					return nil, fmt.Errorf("invalid color %q for cell (%d, %d)", jc.Color, x, y)
+				}
+				cell |= col.ToCell()
+			}
+			maze.UpdateCellAt(x, y, cell)
+		}
+	}
+	if jr := jm.Robot; jr != nil {
+		o, ok := name2Orientation[jr.Facing]
+		if !ok {
+			return nil, fmt.Errorf("invalid robot facing %q", jr.Facing)
+		}
+		if jr.X < 0 || jr.X >= jm.Width || jr.Y < 0 || jr.Y >= jm.Height {
+			return nil, fmt.Errorf("robot position (%d, %d) outside the maze", jr.X, jr.Y)
+		}
+		maze.robot = &Robot{
+			Position:    Position{X: jr.X, Y: jr.Y},
+			Orientation: o,
+		}
+	}
+	return maze, nil
+}
+
+var name2Color = map[string]Color{
+	Red.String():    Red,
+	Yellow.String(): Yellow,
+	Blue.String():   Blue,
+}
+
+var name2Orientation = map[string]Orientation{
+	North.String(): North,
+	East.String():  East,
+	South.String(): South,
+	West.String():  West,
+}
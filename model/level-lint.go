@@ -0,0 +1,86 @@
+package model
+
+// LintLevel returns warnings about things in a level that are allowed but
+// are probably mistakes.
+func LintLevel(l *Level) []*ParseError {
+	return lintLevel(l, nil)
+}
+
+// LintLevelString parses a level in .r2f format like LevelFromString.  It
+// also returns the same warnings as LintLevel, with line numbers, as well as
+// warnings about the text of the level.
+func LintLevelString(defaultName string, s string) (*Level, []*ParseError, error) {
+	lvl, src, err := parseLevel(defaultName, s)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings := append([]*ParseError{}, src.unknownKeys...)
+	if w := src.checkBottomRow(); w != nil {
+		warnings = append(warnings, w)
+	}
+	warnings = append(warnings, lintLevel(lvl, src)...)
+	return lvl, warnings, nil
+}
+
+func lintLevel(l *Level, src *levelSource) []*ParseError {
+	var warnings []*ParseError
+	warn := func(line, col int, format string, args ...interface{}) {
+		warnings = append(warnings, parseErrorf(line, col, format, args...))
+	}
+	if l.BoardWidth < 1 || l.BoardHeigth < 1 {
+		warn(0, 0, "invalid board size %dx%d", l.BoardWidth, l.BoardHeigth)
+	}
+	if l.ChipCost < 0 {
+		warn(0, 0, "negative chip cost %d", l.ChipCost)
+	}
+	if l.MoveCost < 0 {
+		warn(0, 0, "negative move cost %d", l.MoveCost)
+	}
+	m := l.Maze
+	if m.robot == nil {
+		warn(src.mazeLineNumber(), 0, "the maze has no robot")
+	}
+	if m.flags == 0 {
+		warn(src.mazeLineNumber(), 0, "the maze has no flags")
+	}
+	if m.robot != nil {
+		for _, pos := range AnalyzeMaze(m).SealedFlags {
+			line, col := src.cellPos(pos.X, pos.Y)
+			warn(line, col, "the robot cannot reach the flag at %s", pos)
+		}
+	}
+	return warnings
+}
+
+// checkBottomRow warns if the last row of the maze does not match the first
+// one.  It is ignored by MazeFromString as the maze wraps around, so the
+// south walls of the bottom row are the north walls of the top row.
+func (src *levelSource) checkBottomRow() *ParseError {
+	top, bottom := src.mazeRows[0], src.mazeRows[len(src.mazeRows)-1]
+	for j := 0; j < len(top) && j < len(bottom); j++ {
+		if j%3 != 0 && top[j] != bottom[j] {
+			return parseErrorf(
+				src.mazeLine+len(src.mazeRows)-1, j+1,
+				"bottom walls do not match the top walls, which are the ones used",
+			)
+		}
+	}
+	return nil
+}
+
+// mazeLineNumber returns the line of the start of the maze, or 0 if unknown.
+func (src *levelSource) mazeLineNumber() int {
+	if src == nil {
+		return 0
+	}
+	return src.mazeLine
+}
+
+// cellPos returns the line and column of the cell at (x, y) in the maze, or
+// 0, 0 if unknown.
+func (src *levelSource) cellPos(x, y int) (int, int) {
+	if src == nil {
+		return 0, 0
+	}
+	return src.mazeLine + 2*y + 1, 3*x + 2
+}
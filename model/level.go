@@ -1,13 +1,19 @@
 package model
 
 import (
-	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
 )
 
+const (
+	defaultBoardWidth  = 9
+	defaultBoardHeight = 9
+	defaultChipCost    = 10
+	defaultMoveCost    = 1
+)
+
 type Level struct {
 	Name        string
 	Maze        *Maze
@@ -17,58 +23,150 @@ type Level struct {
 	MoveCost    int
 }
 
+// NewLevel returns a level for the maze with the default settings.
+func NewLevel(name string, m *Maze) *Level {
+	lvl := defaultLevel(name)
+	lvl.Maze = m
+	return &lvl
+}
+
+func defaultLevel(name string) Level {
+	return Level{
+		Name:        name,
+		BoardWidth:  defaultBoardWidth,
+		BoardHeigth: defaultBoardHeight,
+		ChipCost:    defaultChipCost,
+		MoveCost:    defaultMoveCost,
+	}
+}
+
+// LevelFromString parses a level in .r2f format.  When the level is invalid
+// the error returned has type ParseErrors.
 func LevelFromString(defaultName string, s string) (*Level, error) {
-	lvl := Level{
-		Name:        defaultName,
-		BoardWidth:  9,
-		BoardHeigth: 9,
-		ChipCost:    10,
-		MoveCost:    1,
+	lvl, _, err := parseLevel(defaultName, s)
+	if err != nil {
+		return nil, err
 	}
-	var parseErrors []string
+	return lvl, nil
+}
+
+// levelSource records where things were found in the text of a level.
+type levelSource struct {
+	mazeLine    int // Line of the first row of the maze
+	mazeRows    []string
+	unknownKeys []*ParseError
+	otherKeys   []keyVal // Unknown keys and their values, in order
+}
+
+func parseLevel(defaultName string, s string) (*Level, *levelSource, error) {
+	lvl := defaultLevel(defaultName)
+	src := &levelSource{}
+	var parseErrors ParseErrors
+	line := 1
 	for _, kv := range parseString(s) {
+		keyLine := line
+		line += strings.Count(kv.v, "\n")
+		if kv.k == "" && strings.TrimSpace(kv.v) == "" {
+			continue
+		}
 		var err error
 		switch strings.ToLower(kv.k) {
 		case "", "maze":
-			m, err := MazeFromString(kv.v)
+			valueLine := keyLine + strings.Count(kv.v[:len(kv.v)-len(strings.TrimLeft(kv.v, " \t\r\n"))], "\n")
+			var m *Maze
+			m, err = MazeFromString(kv.v)
 			if err == nil {
 				lvl.Maze = m
+				src.mazeLine = valueLine
+				src.mazeRows = strings.Split(strings.TrimSpace(kv.v), "\n")
+			} else if perr, ok := err.(*ParseError); ok {
+				parseErrors = append(parseErrors, perr.moveLines(valueLine-1))
+				continue
 			}
-			lvl.Maze = m
 		case "name":
 			lvl.Name = strings.TrimSpace(kv.v)
 		case "boardwidth":
-			w, err := parseInt(kv.v)
+			var w int
+			w, err = parseInt(kv.v)
 			if err == nil {
 				lvl.BoardWidth = w
 			}
 		case "boardheight":
-			h, err := parseInt(kv.v)
+			var h int
+			h, err = parseInt(kv.v)
 			if err == nil {
 				lvl.BoardHeigth = h
 			}
 		case "chipcost":
-			c, err := parseInt(kv.v)
+			var c int
+			c, err = parseInt(kv.v)
 			if err == nil {
 				lvl.ChipCost = c
 			}
 		case "movecost":
-			c, err := parseInt(kv.v)
+			var c int
+			c, err = parseInt(kv.v)
 			if err == nil {
 				lvl.MoveCost = c
 			}
+		default:
+			src.unknownKeys = append(src.unknownKeys, parseErrorf(keyLine, 1, "unknown key %q is ignored", kv.k))
+			src.otherKeys = append(src.otherKeys, kv)
 		}
 		if err != nil {
-			parseErrors = append(parseErrors, fmt.Sprintf("%s: %s", kv.k, err))
+			parseErrors = append(parseErrors, parseErrorf(keyLine, 0, "%s: %s", kv.k, err))
 		}
 	}
-	if lvl.Maze == nil {
-		parseErrors = append(parseErrors, "Maze definition is missing")
+	if lvl.Maze == nil && len(parseErrors) == 0 {
+		parseErrors = append(parseErrors, &ParseError{Msg: errMazeMissing.Error()})
 	}
 	if len(parseErrors) != 0 {
-		return nil, errors.New(strings.Join(parseErrors, "\n"))
+		return nil, nil, parseErrors
+	}
+	return &lvl, src, nil
+}
+
+// LevelToString returns the level in the format understood by
+// LevelFromString.  Only settings that differ from the defaults are written
+// out; the name is omitted if it is defaultName.
+func LevelToString(l *Level, defaultName string) string {
+	var b strings.Builder
+	b.WriteString(l.Maze.String())
+	if l.Name != defaultName {
+		fmt.Fprintf(&b, "Name: %s\n", l.Name)
+	}
+	if l.BoardWidth != defaultBoardWidth {
+		fmt.Fprintf(&b, "BoardWidth: %d\n", l.BoardWidth)
+	}
+	if l.BoardHeigth != defaultBoardHeight {
+		fmt.Fprintf(&b, "BoardHeight: %d\n", l.BoardHeigth)
+	}
+	if l.ChipCost != defaultChipCost {
+		fmt.Fprintf(&b, "ChipCost: %d\n", l.ChipCost)
+	}
+	if l.MoveCost != defaultMoveCost {
+		fmt.Fprintf(&b, "MoveCost: %d\n", l.MoveCost)
+	}
+	return b.String()
+}
+
+// formatLevelString returns the level in .r2f format like LevelToString,
+// followed by the keys unknown to this version so that they are not lost.
+func formatLevelString(defaultName string, s string) (string, error) {
+	lvl, src, err := parseLevel(defaultName, s)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString(LevelToString(lvl, defaultName))
+	for _, kv := range src.otherKeys {
+		b.WriteString(kv.k + ":")
+		if v := strings.TrimSpace(kv.v); v != "" {
+			b.WriteString(" " + v)
+		}
+		b.WriteByte('\n')
 	}
-	return &lvl, nil
+	return b.String(), nil
 }
 
 func (l *Level) BoardSize() (int, int) {
@@ -65,3 +65,54 @@ OtherKey:other value`,
 		})
 	}
 }
+
+func TestLevelFromString_errorPositions(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want ParseErrors
+	}{
+		{
+			name: "bad char in maze after key",
+			s: `Name: foo
+Maze:
++--+--+
+|R> RX|
++--+--+`,
+			want: ParseErrors{{Line: 4, Col: 6, Msg: "wrong char: one of [F<>^v ] allowed"}},
+		},
+		{
+			name: "bad value",
+			s: `
++--+
+|R>|
++--+
+MoveCost: lots`,
+			want: ParseErrors{{Line: 5, Msg: `MoveCost: strconv.Atoi: parsing "lots": invalid syntax`}},
+		},
+		{
+			name: "no maze",
+			s:    `Name: foo`,
+			want: ParseErrors{{Msg: "Maze definition is missing"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LevelFromString("test", tt.s)
+			if !reflect.DeepEqual(err, tt.want) {
+				t.Errorf("LevelFromString() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLevelFromString_lenient(t *testing.T) {
+	lvl, err := LevelFromString("test", "+--+--+  \r\n|R> RF|\r\n+     +\n|R  R |\n+--+--+\n")
+	if err != nil {
+		t.Fatalf("LevelFromString() error = %v", err)
+	}
+	want := "+--+--+\n|R> RF|\n+  .  +\n|R  R |\n+--+--+\n"
+	if got := lvl.Maze.String(); got != want {
+		t.Errorf("Maze.String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,124 @@
+package model
+
+// Above this number of search states, AnalyzeMaze does not work out the
+// minimum number of moves needed to capture all the flags.  Each state is a
+// robot position, a facing and a set of flags captured.
+const maxMinMovesStates = 1 << 22
+
+// MazeAnalysis is what can be found out about a maze without a circuit board,
+// only from its walls and the starting position of the robot.
+type MazeAnalysis struct {
+	Reachable      []Position // Cells the robot can get to
+	ReachableFlags []Position // Uncaptured flags the robot can get to
+	SealedFlags    []Position // Uncaptured flags the robot can never get to
+
+	// MinMoves is the least number of commands (moves forward and turns)
+	// needed to capture all the flags, or -1 if that cannot be done or if
+	// there are too many flags to work it out.
+	MinMoves int
+}
+
+// AnalyzeMaze works out which cells and flags the robot can reach, going
+// around the walls of the maze, and how many commands it needs at least to
+// capture all the flags.  Cells are listed in reading order.
+func AnalyzeMaze(m *Maze) *MazeAnalysis {
+	a := &MazeAnalysis{MinMoves: -1}
+	reachable := make([]bool, len(m.cells))
+	if m.robot != nil {
+		m.walkCells(m.cellIndex(m.robot.X, m.robot.Y), reachable)
+	}
+	for i, c := range m.cells {
+		pos := Position{X: i % m.width, Y: i / m.width}
+		if reachable[i] {
+			a.Reachable = append(a.Reachable, pos)
+		}
+		if !c.Flag() || c.Captured() {
+			continue
+		}
+		if reachable[i] {
+			a.ReachableFlags = append(a.ReachableFlags, pos)
+		} else {
+			a.SealedFlags = append(a.SealedFlags, pos)
+		}
+	}
+	if m.robot != nil && len(a.SealedFlags) == 0 {
+		a.MinMoves = m.minMoves(a.ReachableFlags)
+	}
+	return a
+}
+
+// walkCells marks all the cells that can be reached from cell i.
+func (m *Maze) walkCells(i int, reached []bool) {
+	reached[i] = true
+	todo := []int{i}
+	for len(todo) > 0 {
+		i, todo = todo[0], todo[1:]
+		x, y := i%m.width, i/m.width
+		for o := North; o <= West; o++ {
+			if m.HasWallAt(x, y, o) {
+				continue
+			}
+			v := o.VelocityForward()
+			if j := m.cellIndex(x+v.Dx, y+v.Dy); !reached[j] {
+				reached[j] = true
+				todo = append(todo, j)
+			}
+		}
+	}
+}
+
+// minMoves does a breadth first search for the shortest sequence of commands
+// that captures all the flags.  As in AdvanceRobot, a flag is captured at the
+// end of any command that leaves the robot on it.
+func (m *Maze) minMoves(flags []Position) int {
+	if len(flags) == 0 {
+		return 0
+	}
+	if len(flags) >= 31 || len(m.cells)*4<<len(flags) > maxMinMovesStates {
+		return -1
+	}
+	flagBit := make([]int, len(m.cells))
+	for i, pos := range flags {
+		flagBit[m.cellIndex(pos.X, pos.Y)] = 1 << i
+	}
+	allFlags := 1<<len(flags) - 1
+
+	type state struct {
+		cell   int
+		facing Orientation
+		flags  int
+	}
+	stateIndex := func(s state) int {
+		return (s.flags*len(m.cells)+s.cell)*4 + int(s.facing)
+	}
+	seen := make([]bool, len(m.cells)*4<<len(flags))
+	start := state{cell: m.cellIndex(m.robot.X, m.robot.Y), facing: m.robot.Orientation}
+	seen[stateIndex(start)] = true
+	todo := []state{start}
+	for moves := 1; len(todo) > 0; moves++ {
+		var next []state
+		for _, s := range todo {
+			x, y := s.cell%m.width, s.cell/m.width
+			fwd := s
+			if !m.HasWallAt(x, y, s.facing) {
+				v := s.facing.VelocityForward()
+				fwd.cell = m.cellIndex(x+v.Dx, y+v.Dy)
+			}
+			left, right := s, s
+			left.facing = s.facing.Rotate(Left)
+			right.facing = s.facing.Rotate(Right)
+			for _, n := range [...]state{fwd, left, right} {
+				n.flags |= flagBit[n.cell]
+				if n.flags == allFlags {
+					return moves
+				}
+				if i := stateIndex(n); !seen[i] {
+					seen[i] = true
+					next = append(next, n)
+				}
+			}
+		}
+		todo = next
+	}
+	return -1
+}
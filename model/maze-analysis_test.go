@@ -0,0 +1,87 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeMaze(t *testing.T) {
+	tests := []struct {
+		name           string
+		maze           string
+		reachable      int
+		reachableFlags []Position
+		sealedFlags    []Position
+		minMoves       int
+	}{
+		{
+			name:           "Straight",
+			maze:           straightLevel,
+			reachable:      8,
+			reachableFlags: []Position{{3, 0}, {7, 0}},
+			minMoves:       7,
+		},
+		{
+			name: "Wrap around",
+			maze: `
++--+--+--+--+
+|RF R  R  R<|
++--+--+--+--+`,
+			reachable:      4,
+			reachableFlags: []Position{{0, 0}},
+			minMoves:       3,
+		},
+		{
+			name: "Turns",
+			maze: `
++--+--+
+|R>|  |
++  +  +
+|   RF|
++--+--+`,
+			reachable:      4,
+			reachableFlags: []Position{{1, 1}},
+			minMoves:       4,
+		},
+		{
+			name: "Sealed flag",
+			maze: `
++--+--+--+
+|R>|RF R |
++--+--+--+`,
+			reachable:   1,
+			sealedFlags: []Position{{1, 0}},
+			minMoves:    -1,
+		},
+		{
+			name: "No flags",
+			maze: `
++--+--+
+|R>|R |
++--+--+`,
+			reachable: 1,
+			minMoves:  0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := MazeFromString(tt.maze)
+			if err != nil {
+				t.Fatal(err)
+			}
+			a := AnalyzeMaze(m)
+			if len(a.Reachable) != tt.reachable {
+				t.Errorf("reachable cells = %d, want %d", len(a.Reachable), tt.reachable)
+			}
+			if !reflect.DeepEqual(a.ReachableFlags, tt.reachableFlags) {
+				t.Errorf("reachable flags = %v, want %v", a.ReachableFlags, tt.reachableFlags)
+			}
+			if !reflect.DeepEqual(a.SealedFlags, tt.sealedFlags) {
+				t.Errorf("sealed flags = %v, want %v", a.SealedFlags, tt.sealedFlags)
+			}
+			if a.MinMoves != tt.minMoves {
+				t.Errorf("min moves = %d, want %d", a.MinMoves, tt.minMoves)
+			}
+		})
+	}
+}
@@ -1,8 +1,6 @@
 package model
 
 import (
-	"errors"
-	"fmt"
 	"log"
 	"strings"
 )
@@ -190,27 +188,33 @@ func (m *Maze) HasWallAt(x, y int, o Orientation) bool {
 }
 
 func wrongCharErr(i, j int, allowed string) error {
-	return fmt.Errorf("wrong char line %d col %d: one of [%s] allowed", i+1, j+1, allowed)
+	return parseErrorf(i+1, j+1, "wrong char: one of [%s] allowed", allowed)
 }
 
+// MazeFromString parses a maze.  Leading and trailing blank lines as well as
+// trailing whitespace on each line are ignored.  Errors returned have type
+// *ParseError, with line numbers counted from the first non blank line.
 func MazeFromString(s string) (*Maze, error) {
 	s = strings.TrimSpace(s)
 	rows := strings.Split(s, "\n")
+	for i, row := range rows {
+		rows[i] = strings.TrimRight(row, " \t\r")
+	}
 	if len(rows)%2 != 1 {
-		return nil, errors.New("need odd number of lines")
+		return nil, parseErrorf(len(rows), 0, "need odd number of lines")
 	}
 	height := (len(rows) - 1) / 2
 	if height == 0 {
-		return nil, errors.New("need at least 1 row")
+		return nil, parseErrorf(1, 0, "need at least 1 row")
 	}
 	lr0 := len(rows[0])
 	width := (lr0 - 1) / 3
 	if width*3+1 != lr0 {
-		return nil, errors.New("wrong length for line 1")
+		return nil, parseErrorf(1, 0, "wrong length for line")
 	}
 	for i, row := range rows {
 		if len(row) != lr0 {
-			return nil, fmt.Errorf("wrong length for line %d", i+1)
+			return nil, parseErrorf(i+1, 0, "wrong length for line: expected %d chars, got %d", lr0, len(row))
 		}
 	}
 	maze := NewMaze(width, height)
@@ -236,10 +240,10 @@ func MazeFromString(s string) (*Maze, error) {
 					switch c {
 					case '+':
 						maze.UpdateCellAt(x, y, CF)
-					case '.':
+					case '.', ' ':
 						// No corner
 					default:
-						return nil, wrongCharErr(i, j, "+.")
+						return nil, wrongCharErr(i, j, "+. ")
 					}
 				case 1:
 					// Horizontal wall
@@ -309,7 +313,7 @@ func MazeFromString(s string) (*Maze, error) {
 						maze.UpdateCellAt(x, y, FF)
 					case '>', '<', '^', 'v':
 						if maze.robot != nil {
-							return nil, fmt.Errorf("Only one robot allowed (second defined line %d, col %d)", i, j)
+							return nil, parseErrorf(i+1, j+1, "only one robot allowed")
 						}
 						maze.robot = &Robot{
 							Position: Position{
@@ -321,7 +325,7 @@ func MazeFromString(s string) (*Maze, error) {
 					case ' ':
 						// Nothing
 					default:
-						return nil, wrongCharErr(i, j, "F ")
+						return nil, wrongCharErr(i, j, "F<>^v ")
 					}
 				}
 			}
@@ -330,6 +334,62 @@ func MazeFromString(s string) (*Maze, error) {
 	return maze, nil
 }
 
+// String returns the maze in the format understood by MazeFromString.  If
+// the robot stands on a flag, the flag is not shown.
+func (m *Maze) String() string {
+	return m.write('F')
+}
+
+// write returns the maze in .r2f form, with captured flags shown as
+// capturedFlag.
+func (m *Maze) write(capturedFlag byte) string {
+	var b strings.Builder
+	for y := 0; y <= m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			cell := m.CellAt(x, y)
+			if cell.CornerWall() {
+				b.WriteByte('+')
+			} else {
+				b.WriteByte('.')
+			}
+			if cell.NorthWall() {
+				b.WriteString("--")
+			} else {
+				b.WriteString("  ")
+			}
+		}
+		b.WriteString("+\n")
+		if y == m.height {
+			break
+		}
+		for x := 0; x < m.width; x++ {
+			cell := m.CellAt(x, y)
+			if cell.WestWall() {
+				b.WriteByte('|')
+			} else {
+				b.WriteByte(' ')
+			}
+			b.WriteByte(color2byte[cell.Color()])
+			switch {
+			case m.robotAt(x, y):
+				b.WriteRune(orientation2Rune[m.robot.Orientation])
+			case cell.Captured():
+				b.WriteByte(capturedFlag)
+			case cell.Flag():
+				b.WriteByte('F')
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString("|\n")
+	}
+	return b.String()
+}
+
+func (m *Maze) robotAt(x, y int) bool {
+	return m.robot != nil && m.cellIndex(m.robot.X, m.robot.Y) == m.cellIndex(x, y)
+}
+
 func (m *Maze) Size() (int, int) {
 	return m.width, m.height
 }
@@ -338,6 +398,12 @@ func (m *Maze) Robot() *Robot {
 	return m.robot
 }
 
+// SetRobot puts the robot at a position in the maze, facing the given
+// orientation.
+func (m *Maze) SetRobot(pos Position, o Orientation) {
+	m.robot = &Robot{Position: pos, Orientation: o}
+}
+
 func (m *Maze) StopRobot() {
 	*m.robot = m.robot.Stop()
 }
@@ -370,3 +436,17 @@ var rune2Orientation = map[rune]Orientation{
 	'^': North,
 	'v': South,
 }
+
+var orientation2Rune = map[Orientation]rune{
+	East:  '>',
+	West:  '<',
+	North: '^',
+	South: 'v',
+}
+
+var color2byte = map[Color]byte{
+	NoColor: ' ',
+	Red:     'R',
+	Yellow:  'Y',
+	Blue:    'B',
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A ParseError describes a problem found when parsing a level.  Line and Col
+// start at 1 and are 0 when unknown.
+type ParseError struct {
+	Line, Col int
+	Msg       string
+}
+
+func (e *ParseError) Error() string {
+	switch {
+	case e.Line == 0:
+		return e.Msg
+	case e.Col == 0:
+		return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+	default:
+		return fmt.Sprintf("line %d col %d: %s", e.Line, e.Col, e.Msg)
+	}
+}
+
+// ParseErrors is returned by LevelFromString when there are several problems
+// with a level.
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func parseErrorf(line, col int, format string, args ...interface{}) *ParseError {
+	return &ParseError{Line: line, Col: col, Msg: fmt.Sprintf(format, args...)}
+}
+
+// moveLines returns a copy of the error moved down by n lines.
+func (e *ParseError) moveLines(n int) *ParseError {
+	moved := *e
+	if moved.Line != 0 {
+		moved.Line += n
+	}
+	return &moved
+}
@@ -0,0 +1,169 @@
+package model
+
+// A Program is a circuit board compiled to a flat list of instructions, with
+// arrows resolved to jumps and empty slots skipped.  Running a program gives
+// the same results as running the board with a LevelController but is much
+// faster, as nothing is decoded or activated on the board at each step.
+type Program struct {
+	instrs []instruction
+	start  int // Index of the start chip, or -1 if there is none
+	chips  int // As returned by CircuitBoard.ChipCount
+}
+
+type opCode byte
+
+const (
+	opDeadEnd opCode = iota // The run stops
+	opJump                  // Go to next[0] (start chip)
+	opCommand               // Give com to the robot then go to next[0]
+	opTest                  // Go to next[0] if test is true, next[1] otherwise
+)
+
+// Instruction 0 is always a dead end, so branches that lead nowhere jump to
+// it.
+const deadEndInstr = 0
+
+type instruction struct {
+	op   opCode
+	com  Command
+	test ChipType
+	next [2]int
+}
+
+// CompileBoard compiles the circuit board into a program.
+func CompileBoard(b *CircuitBoard) *Program {
+	flow := NewBoardFlow(b)
+	p := &Program{
+		instrs: make([]instruction, 1, len(flow.Chips)+1),
+		start:  -1,
+		chips:  b.ChipCount(),
+	}
+	index := map[Position]int{}
+	for _, c := range flow.Chips {
+		index[c.Pos] = len(p.instrs)
+		p.instrs = append(p.instrs, instruction{})
+	}
+	for _, c := range flow.Chips {
+		in := &p.instrs[index[c.Pos]]
+		for i, br := range c.Branches {
+			if br.End == ToChip {
+				in.next[i] = index[br.To]
+			} else {
+				in.next[i] = deadEndInstr
+			}
+		}
+		com, _ := Chip(c.Type).Command(NoColor, false)
+		switch {
+		case c.Type.IsDecision():
+			in.op = opTest
+			in.test = c.Type
+		case com == NoCommand:
+			in.op = opJump
+		case c.Branches[0].StopsAtChip():
+			// The command is not given if the chip has nowhere to go
+			in.op = opDeadEnd
+		default:
+			in.op = opCommand
+			in.com = com
+		}
+	}
+	if start := flow.Start(); start != nil {
+		p.start = index[start.Pos]
+	}
+	return p
+}
+
+// Run runs the program on the level like RunLevel.
+func (p *Program) Run(level *Level, maxSteps int) RunResult {
+	if p.start < 0 {
+		return RunResult{Outcome: NoStart, Maze: level.Maze.Clone()}
+	}
+	r := programRun{
+		prog:    p,
+		maze:    level.Maze.Clone(),
+		pc:      p.start,
+		visited: make([]int, len(p.instrs)),
+		score:   level.ChipCost * p.chips,
+	}
+	result := RunResult{Chips: p.chips}
+	for {
+		// This is LevelController.Advance
+		r.maze.AdvanceRobot()
+		if r.maze.FlagsRemaining() == 0 {
+			r.maze.StopRobot()
+			result.Outcome = Won
+			break
+		}
+		com := r.nextCommand(level.MoveCost)
+		r.maze.CommandRobot(com)
+		if r.deadEnd {
+			result.Outcome = DeadEnd
+			break
+		}
+		if result.Steps == maxSteps {
+			// Like LevelController.cancelCommand
+			result.Outcome = OutOfSteps
+			r.score -= level.MoveCost
+			r.maze.StopRobot()
+			break
+		}
+		result.Steps++
+	}
+	result.Cost = r.score
+	result.FlagsCaptured = r.maze.FlagsCaptured()
+	result.Maze = r.maze
+	return result
+}
+
+// programRun is the state of a run of a program.
+type programRun struct {
+	prog    *Program
+	maze    *Maze
+	pc      int
+	score   int
+	deadEnd bool
+
+	// visited[i] == pass if instruction i was executed during the current
+	// call to nextCommand, which is how a LevelController detects loops
+	// that never give a command.
+	visited []int
+	pass    int
+}
+
+// nextCommand is LevelController.NextCommand for a program.
+func (r *programRun) nextCommand(moveCost int) Command {
+	if r.deadEnd {
+		return NoCommand
+	}
+	r.pass++
+	var (
+		robot      = r.maze.robot
+		wallAhead  = r.maze.HasWallAt(robot.X, robot.Y, robot.Orientation)
+		floorColor = r.maze.CellAt(robot.X, robot.Y).Color()
+	)
+	for {
+		in := &r.prog.instrs[r.pc]
+		r.visited[r.pc] = r.pass
+		switch in.op {
+		case opDeadEnd:
+			r.deadEnd = true
+			return NoCommand
+		case opCommand:
+			r.pc = in.next[0]
+			r.score += moveCost
+			return in.com
+		case opTest:
+			if _, yes := Chip(in.test).Command(floorColor, wallAhead); yes {
+				r.pc = in.next[0]
+			} else {
+				r.pc = in.next[1]
+			}
+		default:
+			r.pc = in.next[0]
+		}
+		if r.visited[r.pc] == r.pass {
+			r.deadEnd = true
+			return NoCommand
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package model
+
+import (
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"os"
+	"reflect"
+	"testing"
+)
+
+const colorsLevel = `
++--+--+--+--+
+|R  Y |B |RF|
++  .  +  +  +
+|R> B |R  Y |
++  .  +  +  +
+|B  R  Y |RF|
++--+--+--+--+`
+
+func TestProgram_Run(t *testing.T) {
+	level, err := LevelFromString("straight", straightLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		board    string
+		maxSteps int
+	}{
+		{name: "Won", board: forwardLoopBoard, maxSteps: 100},
+		{name: "Out of steps", board: forwardLoopBoard, maxSteps: 3},
+		{name: "Dead end", board: "|ST -> MF|", maxSteps: 100},
+		{
+			name: "Decisions",
+			board: `
+|ST -> W? n> MF|
+|      yv     v|
+|      TL -> ..|`,
+			maxSteps: 100,
+		},
+		{
+			name: "Loop without commands",
+			board: `
+|ST -> W? y> Y?|
+|       ^     v|
+|      .. <- ..|`,
+			maxSteps: 100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board, err := CircuitBoardFromString(tt.board)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := RunLevel(level, board, tt.maxSteps)
+			got := CompileBoard(board).Run(level, tt.maxSteps)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+			checkRunCost(t, level, got)
+		})
+	}
+}
+
+// TestProgram_RunRandom checks that programs give the same results as
+// LevelController on random boards, including ones a player cannot make.
+func TestProgram_RunRandom(t *testing.T) {
+	defer log.SetOutput(os.Stderr)
+	log.SetOutput(ioutil.Discard)
+	var levels []*Level
+	for _, s := range []string{straightLevel, colorsLevel} {
+		level, err := LevelFromString("test", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		levels = append(levels, level)
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		board := randomBoard(rnd, 4, 3)
+		for _, level := range levels {
+			want := RunLevel(level, board, 50)
+			got := CompileBoard(board).Run(level, 50)
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("board:\n%s\ngot %+v, want %+v", board, got, want)
+			}
+			checkRunCost(t, level, got)
+		}
+	}
+}
+
+// randomBoard returns a board with random chips and arrows, which may point
+// off the board.
+func randomBoard(rnd *rand.Rand, width, height int) *CircuitBoard {
+	b := &CircuitBoard{width: width, height: height, chips: make([]Chip, width*height)}
+	for i := range b.chips {
+		c := Chip(0)
+		if rnd.Intn(3) > 0 {
+			c = c.WithType(ChipType(2 + rnd.Intn(10)))
+		}
+		if rnd.Intn(5) > 0 {
+			c = c.WithArrowYes(Orientation(rnd.Intn(4)))
+		}
+		if rnd.Intn(5) > 0 {
+			c = c.WithArrowNo(Orientation(rnd.Intn(4)))
+		}
+		b.chips[i] = c
+	}
+	if rnd.Intn(10) > 0 {
+		b.startPos = Position{rnd.Intn(width), rnd.Intn(height)}
+		b.hasStartPos = true
+		i := b.chipIndex(b.startPos.X, b.startPos.Y)
+		b.chips[i] = b.chips[i].WithType(StartChip)
+	}
+	return b
+}
+
+// bounceBoard moves the robot forward until it is blocked, then turns it
+// right.  It never captures the flag in benchLevel, so runs last until they
+// are out of steps.
+const bounceBoard = `
+|ST -> .. <- ..|
+|       v     ^|
+|.. -> W? n> MF|
+| ^    yv      |
+|.. <- TR      |`
+
+const benchLevel = `
++--+--+--+--+--+--+
+|R> R  R  R  R  R |
++  +  +--+  +  +  +
+|R  Y  B |Y  R  B |
++  +--+  +--+  +  +
+|B  R  Y |RF|R  Y |
++  +  +  +--+  +  +
+|Y  Y  B  R  R  B |
++--+--+--+--+--+--+`
+
+func benchmarkSetup(b *testing.B) (*Level, *CircuitBoard) {
+	level, err := LevelFromString("bench", benchLevel)
+	if err != nil {
+		b.Fatal(err)
+	}
+	board, err := CircuitBoardFromString(bounceBoard)
+	if err != nil {
+		b.Fatal(err)
+	}
+	log.SetOutput(ioutil.Discard)
+	b.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return level, board
+}
+
+func BenchmarkRunLevel(b *testing.B) {
+	level, board := benchmarkSetup(b)
+	for i := 0; i < b.N; i++ {
+		RunLevel(level, board, 1000)
+	}
+}
+
+func BenchmarkProgram_Run(b *testing.B) {
+	level, board := benchmarkSetup(b)
+	prog := CompileBoard(board)
+	for i := 0; i < b.N; i++ {
+		prog.Run(level, 1000)
+	}
+}
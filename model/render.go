@@ -0,0 +1,67 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Render returns the current state of the maze as text, in the same form as
+// String except that captured flags are shown as 'f'.  It is meant for
+// debugging, test failures and tools without a display.
+func (m *Maze) Render() string {
+	return m.write('f')
+}
+
+// Render returns the board as text in the same form as String, with the
+// chips and arrows that were last followed marked: active chips have lower
+// case codes ("::" for an empty slot used as a wire) and active arrows are
+// drawn with '=', 'Y' or 'N'.
+func (b *CircuitBoard) Render() string {
+	return b.write(true)
+}
+
+// Render returns the state of a run as text: the maze, followed by the
+// circuit board if withBoard is true, and a status line.
+func (c *LevelController) Render(withBoard bool) string {
+	s := c.maze.Render()
+	if withBoard {
+		s = sideBySide(s, c.board.Render(), 4)
+	}
+	status := fmt.Sprintf("flags: %d/%d, cost: $%d", c.maze.FlagsCaptured(), c.maze.flags, c.score)
+	switch {
+	case c.GameWon():
+		status += ", won"
+	case c.deadEnd:
+		status += ", dead end"
+	}
+	return s + status + "\n"
+}
+
+// sideBySide puts two blocks of lines next to each other, gap spaces apart.
+func sideBySide(left, right string, gap int) string {
+	leftLines := strings.Split(strings.TrimSuffix(left, "\n"), "\n")
+	rightLines := strings.Split(strings.TrimSuffix(right, "\n"), "\n")
+	width := 0
+	for _, l := range leftLines {
+		if len(l) > width {
+			width = len(l)
+		}
+	}
+	var b strings.Builder
+	for i := 0; i < len(leftLines) || i < len(rightLines); i++ {
+		var l, r string
+		if i < len(leftLines) {
+			l = leftLines[i]
+		}
+		if i < len(rightLines) {
+			r = rightLines[i]
+		}
+		if r == "" {
+			b.WriteString(l)
+		} else {
+			fmt.Fprintf(&b, "%-*s%s", width+gap, l, r)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
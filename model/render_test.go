@@ -0,0 +1,54 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLevelController_Render(t *testing.T) {
+	level, err := LevelFromString("straight", straightLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	board, err := CircuitBoardFromString(forwardLoopBoard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got string
+	RunLevelFunc(level, board, 4, func(c *LevelController) {
+		got = c.Render(true)
+	})
+	want := `
++--+--+--+--+--+--+--+--+    |st => mf|
+|R  R  R  Rf R> R  R  RF|    |=^    =v|
++--+--+--+--+--+--+--+--+    |:: <= ::|
+flags: 1/2, cost: $15
+`
+	if got != strings.TrimPrefix(want, "\n") {
+		t.Errorf("Render() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMaze_Render(t *testing.T) {
+	m, err := MazeFromString(`
++--+--+--+
+|R> YF BF|
++--+--+--+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.CommandRobot(MoveForward)
+	m.AdvanceRobot()
+	m.CommandRobot(PaintBlue)
+	m.AdvanceRobot()
+	m.CommandRobot(MoveForward)
+	m.AdvanceRobot()
+	want := `
++--+--+--+
+|R  Bf B>|
++--+--+--+
+`
+	if got := m.Render(); got != strings.TrimPrefix(want, "\n") {
+		t.Errorf("Render() =\n%s\nwant\n%s", got, want)
+	}
+}
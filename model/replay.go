@@ -0,0 +1,186 @@
+package model
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Version of the replay format written by ReplayToJSON.
+const replayVersion = 1
+
+// A Replay is a record of a run of a circuit board on a level.  It contains
+// enough to run the board again and check that the same things happen, e.g.
+// to check a solution handed in by a student or to reproduce a bug.
+type Replay struct {
+	Version   int    `json:"version"`
+	Level     string `json:"level"`     // Name of the level, for information
+	LevelHash string `json:"levelHash"` // See LevelHash
+	Board     string `json:"board"`     // As returned by CircuitBoard.String
+
+	// Seed is for levels with random elements.  There are none yet so it is
+	// always 0.
+	Seed int64 `json:"seed,omitempty"`
+
+	Steps []ReplayStep `json:"steps"`
+	Final ReplayState  `json:"final"`
+
+	flagsCaptured int // Only used when recording
+}
+
+// A ReplayStep records one call to LevelController.Advance: where the robot
+// got to and what happened on the way, then the command it was given next.
+type ReplayStep struct {
+	Robot   jsonRobot `json:"robot"`
+	Events  []string  `json:"events,omitempty"`
+	Command string    `json:"command"`
+}
+
+// Replay events
+const (
+	CaptureEvent = "capture" // The robot captured a flag
+	CrashEvent   = "crash"   // The robot was told to move into a wall
+	WonEvent     = "won"     // All flags are captured
+	DeadEndEvent = "dead end"
+)
+
+// ReplayState is the state of the run at the end of a replay.
+type ReplayState struct {
+	Outcome       string `json:"outcome"` // "won", "dead end" or "out of steps"
+	Cost          int    `json:"cost"`
+	FlagsCaptured int    `json:"flagsCaptured"`
+	Maze          string `json:"maze"` // As returned by Maze.Render
+}
+
+// LevelHash identifies the content of a level: two levels with the same maze
+// and settings have the same hash, whatever their name.
+func LevelHash(l *Level) string {
+	sum := sha256.Sum256([]byte(LevelToString(l, l.Name)))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewReplay returns an empty replay for a run of the board on the level.
+// Call Record after each step of the run to fill it.
+func NewReplay(level *Level, board *CircuitBoard) *Replay {
+	clean := board.Clone()
+	clean.ClearActiveChips()
+	return &Replay{
+		Version:   replayVersion,
+		Level:     level.Name,
+		LevelHash: LevelHash(level),
+		Board:     clean.String(),
+	}
+}
+
+// Record adds the step just made by c to the replay.  It can be passed to
+// RunLevelFunc.
+func (r *Replay) Record(c *LevelController) {
+	m := c.Maze()
+	robot := m.Robot()
+	step := ReplayStep{
+		Robot: jsonRobot{
+			X:      robot.X,
+			Y:      robot.Y,
+			Facing: robot.Orientation.String(),
+		},
+		Command: c.Command().String(),
+	}
+	if m.FlagsCaptured() > r.flagsCaptured {
+		step.Events = append(step.Events, CaptureEvent)
+		r.flagsCaptured = m.FlagsCaptured()
+	}
+	outcome := OutOfSteps
+	switch {
+	case c.GameWon():
+		step.Events = append(step.Events, WonEvent)
+		outcome = Won
+	case c.DeadEnd():
+		step.Events = append(step.Events, DeadEndEvent)
+		outcome = DeadEnd
+	case c.Crashed():
+		step.Events = append(step.Events, CrashEvent)
+	}
+	r.Steps = append(r.Steps, step)
+	r.Final = ReplayState{
+		Outcome:       outcome.String(),
+		Cost:          c.Score(),
+		FlagsCaptured: m.FlagsCaptured(),
+		Maze:          m.Render(),
+	}
+}
+
+// Verify runs the board of the replay on the level again and returns an
+// error if anything happens differently.
+func (r *Replay) Verify(level *Level) error {
+	if h := LevelHash(level); h != r.LevelHash {
+		return fmt.Errorf("the replay is for a different version of the level (hash %s, not %s)", r.LevelHash, h)
+	}
+	board, err := CircuitBoardFromString(r.Board)
+	if err != nil {
+		return fmt.Errorf("invalid board in replay: %s", err)
+	}
+	c := NewLevelController(level, board)
+	if c == nil {
+		return fmt.Errorf("the board in the replay has no start chip")
+	}
+	check := NewReplay(level, board)
+	for i, step := range r.Steps {
+		c.Advance()
+		check.Record(c)
+		if got := check.Steps[i]; !reflect.DeepEqual(got, step) {
+			return fmt.Errorf("step %d: got %+v, replay has %+v", i+1, got, step)
+		}
+	}
+	if check.Final != r.Final {
+		return fmt.Errorf("final state differs: got\n%+v\nreplay has\n%+v", check.Final, r.Final)
+	}
+	return nil
+}
+
+// ReplayFromJSON parses a replay written by ReplayToJSON.
+func ReplayFromJSON(data []byte) (*Replay, error) {
+	var r Replay
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, jsonParseError(data, err)
+	}
+	if r.Version > replayVersion {
+		return nil, fmt.Errorf("unsupported replay format version %d", r.Version)
+	}
+	return &r, nil
+}
+
+// ReplayToJSON returns the replay in JSON format, with one step per line.
+func ReplayToJSON(r *Replay) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("{\n")
+	writeJSONField(&b, "  ", "version", r.Version)
+	writeJSONField(&b, "  ", "level", r.Level)
+	writeJSONField(&b, "  ", "levelHash", r.LevelHash)
+	writeJSONField(&b, "  ", "board", r.Board)
+	if r.Seed != 0 {
+		writeJSONField(&b, "  ", "seed", r.Seed)
+	}
+	b.WriteString(`  "steps": [` + "\n")
+	for i, step := range r.Steps {
+		data, err := marshalJSON(step, "")
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString("    ")
+		b.Write(data)
+		if i < len(r.Steps)-1 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteString("  ],\n")
+	final, err := marshalJSON(r.Final, "  ")
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(&b, "  \"final\": %s\n}\n", final)
+	return b.Bytes(), nil
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	level, err := LevelFromString("straight", straightLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	board, err := CircuitBoardFromString(forwardLoopBoard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay := NewReplay(level, board)
+	RunLevelFunc(level, board, 100, replay.Record)
+	data, err := ReplayToJSON(replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay, err = ReplayFromJSON(data)
+	if err != nil {
+		t.Fatalf("cannot read back replay: %s\n%s", err, data)
+	}
+	if n := len(replay.Steps); n != 8 {
+		t.Errorf("got %d steps, want 8", n)
+	}
+	if want := (ReplayState{Outcome: "won", Cost: 17, FlagsCaptured: 2}); replay.Final.Outcome != want.Outcome ||
+		replay.Final.Cost != want.Cost || replay.Final.FlagsCaptured != want.FlagsCaptured {
+		t.Errorf("final state = %+v, want %+v", replay.Final, want)
+	}
+	if err := replay.Verify(level); err != nil {
+		t.Errorf("Verify() = %s", err)
+	}
+
+	t.Run("Renamed level", func(t *testing.T) {
+		renamed := *level
+		renamed.Name = "other"
+		if err := replay.Verify(&renamed); err != nil {
+			t.Errorf("Verify() = %s", err)
+		}
+	})
+	t.Run("Different level", func(t *testing.T) {
+		changed := *level
+		changed.MoveCost = 2
+		if err := replay.Verify(&changed); err == nil {
+			t.Error("expected an error")
+		}
+	})
+	t.Run("Different board", func(t *testing.T) {
+		tampered := *replay
+		tampered.Board = strings.Replace(replay.Board, "MF", "TL", 1)
+		if err := tampered.Verify(level); err == nil {
+			t.Error("expected an error")
+		}
+	})
+	t.Run("Different steps", func(t *testing.T) {
+		tampered := *replay
+		tampered.Steps = append([]ReplayStep(nil), replay.Steps...)
+		tampered.Steps[2].Command = TurnLeft.String()
+		if err := tampered.Verify(level); err == nil || !strings.HasPrefix(err.Error(), "step 3:") {
+			t.Errorf("Verify() = %v, want an error at step 3", err)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package model
+
+// Outcome is how a run of a circuit board on a level ended.
+type Outcome int
+
+const (
+	Won        Outcome = iota // All flags were captured
+	DeadEnd                   // The circuit board could not produce a command
+	OutOfSteps                // The step limit was reached
+	NoStart                   // The circuit board has no start chip
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Won:
+		return "won"
+	case DeadEnd:
+		return "dead end"
+	case OutOfSteps:
+		return "out of steps"
+	case NoStart:
+		return "no start chip"
+	default:
+		return "unknown"
+	}
+}
+
+// RunResult summarises a headless run of a circuit board on a level.
+type RunResult struct {
+	Outcome       Outcome
+	Steps         int // Number of commands executed
+	Chips         int // Number of chips on the board (excluding the start chip)
+	Cost          int // Total cost: chips and moves
+	FlagsCaptured int
+	Maze          *Maze // State of the maze at the end of the run
+}
+
+// ChipCost returns the part of the cost due to chips on the board.
+func (r RunResult) ChipCost(l *Level) int {
+	return r.Chips * l.ChipCost
+}
+
+// MoveCost returns the part of the cost due to moves of the robot.
+func (r RunResult) MoveCost(l *Level) int {
+	return r.Cost - r.ChipCost(l)
+}
+
+// RunLevel runs a circuit board on a level without displaying anything, until
+// the level is won, the board reaches a dead end or maxSteps commands have
+// been executed.
+func RunLevel(level *Level, board *CircuitBoard, maxSteps int) RunResult {
+	return RunLevelFunc(level, board, maxSteps, nil)
+}
+
+// RunLevelFunc is like RunLevel but also calls step, if not nil, after each
+// step of the run.
+func RunLevelFunc(level *Level, board *CircuitBoard, maxSteps int, step func(c *LevelController)) RunResult {
+	c := NewLevelController(level, board)
+	if c == nil {
+		return RunResult{Outcome: NoStart, Maze: level.Maze.Clone()}
+	}
+	defer board.ClearActiveChips()
+	result := RunResult{Chips: board.ChipCount()}
+	for {
+		c.Advance()
+		if step != nil {
+			step(c)
+		}
+		switch {
+		case c.GameWon():
+			result.Outcome = Won
+		case c.DeadEnd():
+			result.Outcome = DeadEnd
+		case result.Steps == maxSteps:
+			result.Outcome = OutOfSteps
+			c.cancelCommand()
+		default:
+			result.Steps++
+			continue
+		}
+		break
+	}
+	result.Cost = c.Score()
+	result.FlagsCaptured = c.maze.FlagsCaptured()
+	result.Maze = c.maze
+	return result
+}
@@ -0,0 +1,67 @@
+package model
+
+import "testing"
+
+const straightLevel = `
++--+--+--+--+--+--+--+--+
+|R> R  R  RF R  R  R  RF|
++--+--+--+--+--+--+--+--+`
+
+const forwardLoopBoard = `
+|ST -> MF|
+| ^     v|
+|.. <- ..|`
+
+func TestRunLevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		board    string
+		maxSteps int
+		want     RunResult
+	}{
+		{
+			name:     "Won",
+			board:    forwardLoopBoard,
+			maxSteps: 100,
+			want:     RunResult{Outcome: Won, Steps: 7, Chips: 1, Cost: 17, FlagsCaptured: 2},
+		},
+		{
+			name:     "Out of steps",
+			board:    forwardLoopBoard,
+			maxSteps: 3,
+			want:     RunResult{Outcome: OutOfSteps, Steps: 3, Chips: 1, Cost: 13, FlagsCaptured: 1},
+		},
+		{
+			name:     "Dead end",
+			board:    "|ST -> MF|",
+			maxSteps: 100,
+			want:     RunResult{Outcome: DeadEnd, Chips: 1, Cost: 10},
+		},
+	}
+	level, err := LevelFromString("straight", straightLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board, err := CircuitBoardFromString(tt.board)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := RunLevel(level, board, tt.maxSteps)
+			got.Maze = nil
+			if got != tt.want {
+				t.Errorf("RunLevel() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// checkRunCost checks that the cost of a run is the cost of its chips plus
+// the cost of the commands executed.
+func checkRunCost(t *testing.T, level *Level, r RunResult) {
+	t.Helper()
+	if want := level.ChipCost*r.Chips + level.MoveCost*r.Steps; r.Cost != want {
+		t.Errorf("%s after %d steps: got cost %d, want %d", r.Outcome, r.Steps, r.Cost, want)
+	}
+}
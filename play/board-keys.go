@@ -0,0 +1,160 @@
+package play
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/arnodel/gobot2flags/engine"
+	"github.com/arnodel/gobot2flags/model"
+	"github.com/arnodel/gobot2flags/sprites"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Editing the board with the keyboard.  The arrow keys move a cursor on the
+// board, the other keys act on the slot under the cursor:
+//
+//   Enter               place the selected chip
+//   Delete, Backspace   erase the chip
+//   Shift+Delete        clear the board
+//   Y+arrow key         lay a yes arrow and move the cursor (like dragging)
+//   N+arrow key         lay a no arrow and move the cursor
+//   E+arrow key         erase arrows and move the cursor
+//   Shift+arrow key     select the slots between the cursor and where it
+//                       started
+//   M+arrow key         move the selection
+//   C, X, V             copy, cut or paste at the cursor
+//   T, H                rotate or mirror the selection
+//
+// Undo and redo are Ctrl+Z and Ctrl+Y (see historyShortcut).
+
+var cursorKeys = map[ebiten.Key]model.Orientation{
+	ebiten.KeyArrowUp:    model.North,
+	ebiten.KeyArrowRight: model.East,
+	ebiten.KeyArrowDown:  model.South,
+	ebiten.KeyArrowLeft:  model.West,
+}
+
+var selectionActionKeys = map[ebiten.Key]sprites.IconType{
+	ebiten.KeyC: sprites.CopyIcon,
+	ebiten.KeyX: sprites.CutIcon,
+	ebiten.KeyV: sprites.PasteIcon,
+	ebiten.KeyT: sprites.RotateIcon,
+	ebiten.KeyH: sprites.MirrorIcon,
+}
+
+// drawingWithKeys returns true if a key for laying or erasing arrows is held,
+// so all the arrows laid until it is released are undone in one step.
+func drawingWithKeys(k *engine.Keyboard) bool {
+	return k.IsPressed(ebiten.KeyY) || k.IsPressed(ebiten.KeyN) || k.IsPressed(ebiten.KeyE)
+}
+
+func (g *View) updateBoardKeys(k *engine.Keyboard) {
+	if k.HasCommandModifier() {
+		return
+	}
+	if o, ok := cursorKeyRepeated(k); ok {
+		// The first key press only shows the cursor
+		if g.showCursor {
+			g.moveCursor(k, o)
+		}
+		g.showCursor = true
+		return
+	}
+	if !k.IsPressed(ebiten.KeyShift) {
+		g.selectingWithKeys = false
+	}
+	if !g.showCursor {
+		return
+	}
+	cx, cy := g.cursor.X, g.cursor.Y
+	switch {
+	case k.JustPressed(ebiten.KeyEnter):
+		if t := g.chipSelector.selectedType; t != model.NoChip {
+			g.board.SetChipAt(cx, cy, g.board.ChipAt(cx, cy).WithType(t))
+		}
+	case k.JustPressed(ebiten.KeyDelete), k.JustPressed(ebiten.KeyBackspace):
+		if k.IsPressed(ebiten.KeyShift) {
+			g.board.Reset()
+			g.selection = model.Region{}
+		} else {
+			g.board.SetChipAt(cx, cy, g.board.ChipAt(cx, cy).WithType(model.NoChip))
+		}
+	}
+	for key, icon := range selectionActionKeys {
+		if !k.JustPressed(key) {
+			continue
+		}
+		if icon == sprites.PasteIcon {
+			g.selection = model.RegionBetween(g.cursor, g.cursor)
+		}
+		g.applySelectionAction(icon)
+		g.chipSelector.selectIcon(sprites.SelectIcon)
+	}
+}
+
+func cursorKeyRepeated(k *engine.Keyboard) (model.Orientation, bool) {
+	for key, o := range cursorKeys {
+		if k.Repeated(key) {
+			return o, true
+		}
+	}
+	return 0, false
+}
+
+// moveCursor moves the cursor one slot in direction o, doing what the other
+// keys held ask for on the way.
+func (g *View) moveCursor(k *engine.Keyboard, o model.Orientation) {
+	from := g.cursor
+	to := from.Move(o.VelocityForward())
+	if !g.board.Contains(to.X, to.Y) {
+		return
+	}
+	switch {
+	case k.IsPressed(ebiten.KeyM):
+		if g.selection.Contains(from) {
+			g.selection = g.board.Move(g.selection, o.VelocityForward())
+		}
+	case k.IsPressed(ebiten.KeyShift):
+		if !g.selectingWithKeys {
+			g.selectingWithKeys = true
+			g.selectionAnchor = from
+		}
+		g.selection = model.RegionBetween(g.selectionAnchor, to)
+		g.chipSelector.selectIcon(sprites.SelectIcon)
+	case k.IsPressed(ebiten.KeyE):
+		g.board.SetChipAt(from.X, from.Y, g.board.ChipAt(from.X, from.Y).ClearArrow(o))
+		g.board.SetChipAt(to.X, to.Y, g.board.ChipAt(to.X, to.Y).ClearArrow(o.Reverse()))
+	case k.IsPressed(ebiten.KeyY), k.IsPressed(ebiten.KeyN):
+		chip := g.board.ChipAt(from.X, from.Y)
+		arrow := model.ArrowYes
+		if k.IsPressed(ebiten.KeyN) && chip.IsTest() {
+			arrow = model.ArrowNo
+		}
+		g.board.SetChipAt(from.X, from.Y, chip.WithArrow(o, arrow))
+	}
+	g.cursor = to
+}
+
+// cursorStatus describes the slot under the cursor and the selected tool.
+func (g *View) cursorStatus() string {
+	chip := g.board.ChipAt(g.cursor.X, g.cursor.Y)
+	parts := []string{fmt.Sprintf("Slot %s: %s", g.cursor, chip.Type())}
+	if o, ok := chip.ArrowYes(); ok {
+		if chip.IsTest() {
+			parts = append(parts, fmt.Sprintf("yes arrow %s", o))
+		} else {
+			parts = append(parts, fmt.Sprintf("arrow %s", o))
+		}
+	}
+	if o, ok := chip.ArrowNo(); ok && chip.IsTest() {
+		parts = append(parts, fmt.Sprintf("no arrow %s", o))
+	}
+	if t := g.chipSelector.selectedType; t != model.NoChip {
+		parts = append(parts, fmt.Sprintf("Enter places %s", t))
+	}
+	if !g.selection.Empty() {
+		w, h := g.selection.Size()
+		parts = append(parts, fmt.Sprintf("%dx%d selected", w, h))
+	}
+	return strings.Join(parts, " - ")
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/arnodel/gobot2flags/engine"
 	"github.com/arnodel/gobot2flags/model"
 	"github.com/arnodel/gobot2flags/sprites"
+	"github.com/hajimehoshi/ebiten/v2"
 )
 
 var chipTypes = []model.ChipType{
@@ -30,6 +31,38 @@ var arrowTypes = []model.ArrowType{
 var boardIcons = []sprites.IconType{
 	sprites.EraserIcon,
 	sprites.TrashCanIcon,
+	sprites.SelectIcon,
+	sprites.CopyIcon,
+	sprites.CutIcon,
+	sprites.PasteIcon,
+	sprites.RotateIcon,
+	sprites.MirrorIcon,
+	sprites.UndoIcon,
+	sprites.RedoIcon,
+}
+
+// Icons that act on the whole board straight away, then go back to the tool
+// selected before.
+var historyActions = map[sprites.IconType]bool{
+	sprites.UndoIcon: true,
+	sprites.RedoIcon: true,
+}
+
+// Icons that act on the selected slots straight away, then go back to the
+// select tool.
+var selectionActions = map[sprites.IconType]bool{
+	sprites.CopyIcon:   true,
+	sprites.CutIcon:    true,
+	sprites.PasteIcon:  true,
+	sprites.RotateIcon: true,
+	sprites.MirrorIcon: true,
+}
+
+// Keys selecting chips, in the order of chipTypes.
+var chipKeys = []ebiten.Key{
+	ebiten.KeyDigit1, ebiten.KeyDigit2, ebiten.KeyDigit3, ebiten.KeyDigit4,
+	ebiten.KeyDigit5, ebiten.KeyDigit6, ebiten.KeyDigit7, ebiten.KeyDigit8,
+	ebiten.KeyDigit9, ebiten.KeyDigit0, ebiten.KeyMinus,
 }
 
 var boardTilesImages []engine.ImageToDraw
@@ -40,6 +73,7 @@ type boardTiles struct {
 	selectedIcon      sprites.IconType
 	indexSelector     engine.Selector
 	selectedIndex     int
+	toolIndex         int // Last selected index that is not an action
 	grid              engine.Grid
 }
 
@@ -81,6 +115,14 @@ func (b *boardTiles) Update(p engine.PointerStatus) {
 	}
 }
 
+func (b *boardTiles) UpdateKeys(k *engine.Keyboard) {
+	for i, key := range chipKeys {
+		if k.JustPressed(key) {
+			b.selectIndex(i)
+		}
+	}
+}
+
 func (b *boardTiles) selectIndex(idx int) {
 	b.selectedIndex = idx
 
@@ -102,4 +144,21 @@ func (b *boardTiles) selectIndex(idx int) {
 	b.selectedArrowType = selectedArrowType
 	b.selectedType = selectedType
 	b.selectedIcon = selectedIcon
+	if !historyActions[selectedIcon] && !selectionActions[selectedIcon] {
+		b.toolIndex = idx
+	}
+}
+
+// selectTool selects the tool that was selected before the last action.
+func (b *boardTiles) selectTool() {
+	b.selectIndex(b.toolIndex)
+}
+
+func (b *boardTiles) selectIcon(tp sprites.IconType) {
+	for i, icon := range boardIcons {
+		if icon == tp {
+			b.selectIndex(len(chipTypes) + len(arrowTypes) + i)
+			return
+		}
+	}
 }
@@ -2,6 +2,7 @@ package play
 
 import (
 	"image"
+	"image/color"
 
 	"github.com/arnodel/gobot2flags/engine"
 	"github.com/arnodel/gobot2flags/model"
@@ -96,6 +97,45 @@ func (r *CircuitBoardRenderer) DrawCircuitBoard(c engine.Canvas, b *model.Circui
 	}
 }
 
+// Image stretched over selected slots.
+var selectionImage = ebiten.NewImage(1, 1)
+
+func init() {
+	selectionImage.Fill(color.White)
+}
+
+// DrawSelection shades the slots in the region with the given colour, which
+// is usually translucent.
+func (r *CircuitBoardRenderer) DrawSelection(c engine.Canvas, region model.Region, col color.NRGBA) {
+	if region.Empty() {
+		return
+	}
+	w, h := region.Size()
+	opts := ebiten.DrawImageOptions{}
+	opts.GeoM.Scale(float64(w)*r.width, float64(h)*r.height)
+	opts.GeoM.Translate(float64(region.Min.X)*r.width, float64(region.Min.Y)*r.height)
+	opts.ColorM.Scale(float64(col.R)/255, float64(col.G)/255, float64(col.B)/255, float64(col.A)/255)
+	c.DrawImage(selectionImage, &opts)
+}
+
+// DrawCursor draws a frame around the slot p.
+func (r *CircuitBoardRenderer) DrawCursor(c engine.Canvas, p model.Position, col color.NRGBA) {
+	const t = 2 // Thickness of the frame
+	x, y := float64(p.X)*r.width, float64(p.Y)*r.height
+	for _, side := range [][4]float64{
+		{x, y, r.width, t},
+		{x, y + r.height - t, r.width, t},
+		{x, y, t, r.height},
+		{x + r.width - t, y, t, r.height},
+	} {
+		opts := ebiten.DrawImageOptions{}
+		opts.GeoM.Scale(side[2], side[3])
+		opts.GeoM.Translate(side[0], side[1])
+		opts.ColorM.Scale(float64(col.R)/255, float64(col.G)/255, float64(col.B)/255, float64(col.A)/255)
+		c.DrawImage(selectionImage, &opts)
+	}
+}
+
 func (r *CircuitBoardRenderer) CircuitBoardBounds(b *model.CircuitBoard) image.Rectangle {
 	w, h := b.Size()
 	return image.Rect(0, 0, w*32, h*32)
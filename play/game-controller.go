@@ -23,6 +23,13 @@ const (
 var gameControls = []GameControl{Rewind, Play, Step, Pause, FastForward}
 var gameControlIcons = []sprites.IconType{sprites.RewindIcon, sprites.PlayIcon, sprites.StepIcon, sprites.PauseIcon, sprites.FastForwardIcon}
 
+// Keys selecting game controls, apart from space which plays or pauses.
+var gameControlKeys = map[ebiten.Key]GameControl{
+	ebiten.KeyPeriod: Step,
+	ebiten.KeyR:      Rewind,
+	ebiten.KeyF:      FastForward,
+}
+
 type gameControlSelector struct {
 	selectedControl  GameControl
 	selectingControl GameControl
@@ -71,3 +78,18 @@ func (g *gameControlSelector) Update(p engine.PointerStatus) {
 		g.selectingControl = NoControl
 	}
 }
+
+func (g *gameControlSelector) UpdateKeys(k *engine.Keyboard) {
+	if k.JustPressed(ebiten.KeySpace) {
+		if g.selectedControl == Play || g.selectedControl == FastForward {
+			g.selectedControl = Pause
+		} else {
+			g.selectedControl = Play
+		}
+	}
+	for key, ctrl := range gameControlKeys {
+		if k.JustPressed(key) {
+			g.selectedControl = ctrl
+		}
+	}
+}
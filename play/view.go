@@ -5,6 +5,7 @@ import (
 	"image"
 	"image/color"
 	"math"
+	"strings"
 
 	"github.com/arnodel/gobot2flags/engine"
 	"github.com/arnodel/gobot2flags/model"
@@ -18,22 +19,50 @@ type View struct {
 	showBoard           bool
 	proportion          float64
 	level               *model.Level
+	levelErr            error
 	mazeRenderer        *MazeRenderer
 	boardRenderer       *CircuitBoardRenderer
 	board               *model.CircuitBoard
+	history             *model.BoardHistory
 	chipSelector        *boardTiles
 	boardController     *model.LevelController
 	mazeWindow          *engine.Window
 	mazeControlsWindow  *engine.Window
 	boardWindow         *engine.Window
 	boardControlsWindow *engine.Window
+	mazeViewport        engine.Viewport
+	boardViewport       engine.Viewport
 	exitWindow          *engine.Window
 	gameControlSelector *gameControlSelector
 	playing             bool
 	exit                func()
+
+	// Editing with the select tool
+	selection model.Region     // Selected slots, empty if none
+	clipboard *model.BoardClip // Last copied or cut slots
+	dragFrom  model.Position   // Slot where the current drag started
+	dragTo    model.Position   // Slot the current drag is over
+	selecting bool             // Dragging to select slots
+	moving    bool             // Dragging the selection to move it
+
+	// Editing with the keyboard (see board-keys.go)
+	cursor            model.Position
+	showCursor        bool // Only once the cursor has been moved
+	selectionAnchor   model.Position
+	selectingWithKeys bool
+
+	// Problems with the board, only found again when the board changes
+	warnings      []model.BoardWarning
+	warningsBoard *model.CircuitBoard // Copy of the board they are about
+
+	// The current run is recorded in replay until it ends
+	replay   *model.Replay
+	runEnded bool
+	onRunEnd func(*model.Replay)
 }
 
 var _ engine.View = (*View)(nil)
+var _ engine.ExitHandler = (*View)(nil)
 
 func NewView(level *model.Level, exit func()) *View {
 	board := model.NewCircuitBoard(level.BoardWidth, level.BoardHeigth)
@@ -53,6 +82,7 @@ func NewView(level *model.Level, exit func()) *View {
 		level:           level,
 		mazeRenderer:    mazeRenderer,
 		board:           board,
+		history:         model.NewBoardHistory(board),
 		boardRenderer:   &boardRenderer,
 		boardController: model.NewLevelController(level, board),
 		showBoard:       true,
@@ -91,7 +121,8 @@ func (v *View) Update(vc engine.ViewContainer) error {
 	br1, br2 := hSplit(br, int(128*(1-v.proportion)))
 
 	v.boardControlsWindow = engine.CenteredWindow(br1, v.chipSelector.Bounds(), tr)
-	v.boardWindow = engine.CenteredWindow(br2, v.boardRenderer.CircuitBoardBounds(v.board), btr)
+	v.boardViewport.Update(br2, vc.Gestures())
+	v.boardWindow = v.boardViewport.Window(br2, v.boardRenderer.CircuitBoardBounds(v.board), btr)
 
 	// maze
 	var adv int
@@ -105,25 +136,24 @@ func (v *View) Update(vc engine.ViewContainer) error {
 	case FastForward:
 		adv = 5 - v.step%5
 	case Rewind:
-		if v.playing {
-			v.board.ClearActiveChips()
-			v.boardController = nil
-			v.playing = false
-			v.step = 0
-		}
+		v.rewind()
 	}
 	if !v.playing && adv > 0 {
 		boardController := model.NewLevelController(v.level, v.board)
 		if boardController != nil {
 			v.boardController = boardController
 			v.playing = true
+			v.replay = model.NewReplay(v.level, v.board)
+			v.runEnded = false
 		}
 	}
 	if !v.playing {
 		v.gameControlSelector.selectedControl = Rewind
 	} else if v.gameControlSelector.selectedControl != Pause && v.step%60 == 0 {
 		v.step = 0
-		v.boardController.Advance()
+		if !v.runEnded {
+			v.advance()
+		}
 	}
 	if v.playing && adv > 0 {
 		v.count++
@@ -138,7 +168,8 @@ func (v *View) Update(vc engine.ViewContainer) error {
 
 	v.exitWindow = engine.CenteredWindow(mr11, sprites.PlainIcons.Bounds(), ebiten.GeoM{})
 	v.mazeControlsWindow = engine.CenteredWindow(mr12, v.gameControlSelector.Bounds(), tr)
-	v.mazeWindow = engine.CenteredWindow(mr2, v.mazeRenderer.MazeBounds(v.level.Maze), mtr)
+	v.mazeViewport.Update(mr2, vc.Gestures())
+	v.mazeWindow = v.mazeViewport.Window(mr2, v.mazeRenderer.MazeBounds(v.level.Maze), mtr)
 
 	pointer := vc.Pointer()
 
@@ -146,6 +177,15 @@ func (v *View) Update(vc engine.ViewContainer) error {
 		if v.exitWindow.Contains(pointer.CurrentPos()) {
 			v.exit()
 		}
+		for _, vp := range []struct {
+			viewport *engine.Viewport
+			window   *engine.Window
+		}{{&v.boardViewport, v.boardWindow}, {&v.mazeViewport, v.mazeWindow}} {
+			if !vp.viewport.IsReset() && pointer.CurrentPos().In(resetViewRect(vp.window)) {
+				vp.viewport.Reset()
+				pointer.CancelTouch()
+			}
+		}
 		if v.boardWindow.Contains(pointer.CurrentPos()) && !v.showBoard {
 			v.showBoard = true
 			pointer.CancelTouch()
@@ -155,21 +195,117 @@ func (v *View) Update(vc engine.ViewContainer) error {
 		}
 	}
 
+	keyboard := vc.Keyboard()
+	if keyboard.HasCommandModifier() {
+		// Leave shortcuts to the system, apart from the ones for editing
+		keyboard = nil
+	} else if keyboard.JustPressed(ebiten.KeyEscape) {
+		v.exit()
+	} else if keyboard.JustPressed(ebiten.KeyHome) {
+		v.boardViewport.Reset()
+		v.mazeViewport.Reset()
+	}
+
 	if !v.playing {
-		v.updateBoard(pointer)
+		v.updateBoard(pointer, vc.Keyboard())
 	}
-	v.updateMaze(pointer)
+	v.updateBoardWarnings()
+	v.updateMaze(pointer, keyboard)
 	return nil
 }
 
-func (g *View) updateBoard(pointer *engine.PointerTracker) {
+// OnExit pauses the run in progress, so that it does not carry on from
+// where it was left when the view is shown again.
+func (v *View) OnExit(engine.ViewContainer) {
+	switch v.gameControlSelector.selectedControl {
+	case Play, Step, FastForward:
+		v.gameControlSelector.selectedControl = Pause
+	}
+}
+
+// SetLevel replaces the level being played.  The circuit board is kept (it is
+// resized if the new level has a different board size) and any run in
+// progress is rewound.
+func (v *View) SetLevel(level *model.Level) {
+	v.rewind()
+	v.gameControlSelector.selectedControl = Rewind
+	v.level = level
+	v.levelErr = nil
+	if w, h := v.board.Size(); w != level.BoardWidth || h != level.BoardHeigth {
+		v.board = resizeBoard(v.board, level.BoardWidth, level.BoardHeigth)
+		v.history = model.NewBoardHistory(v.board)
+		v.selection = v.board.Clip(v.selection)
+		v.cursor = model.Position{}
+	}
+}
+
+// SetLevelError reports an error loading the level, which is displayed until
+// the next call to SetLevel.
+func (v *View) SetLevelError(err error) {
+	v.levelErr = err
+}
+
+// SetRunEndHandler sets a function to call with the replay of each run
+// that is won or reaches a dead end.
+func (v *View) SetRunEndHandler(f func(*model.Replay)) {
+	v.onRunEnd = f
+}
+
+// advance makes one step of the current run.
+func (v *View) advance() {
+	c := v.boardController
+	c.Advance()
+	v.replay.Record(c)
+	if c.GameWon() || c.DeadEnd() {
+		v.runEnded = true
+		if v.onRunEnd != nil {
+			v.onRunEnd(v.replay)
+		}
+	}
+}
+
+func (v *View) rewind() {
+	if v.playing {
+		v.board.ClearActiveChips()
+		v.boardController = nil
+		v.playing = false
+		v.step = 0
+	}
+}
+
+func (g *View) updateBoard(pointer *engine.PointerTracker, keyboard *engine.Keyboard) {
+	// All the changes made to the board during a touch, e.g. the arrows drawn
+	// by dragging, are undone in one step.
+	if pointer.Status() != engine.Dragging && !drawingWithKeys(keyboard) {
+		defer g.history.Commit()
+	}
 	g.chipSelector.Update(pointer.ForWindow(g.boardControlsWindow))
+	if !keyboard.HasCommandModifier() {
+		g.chipSelector.UpdateKeys(keyboard)
+	}
+	switch icon := g.chipSelector.selectedIcon; {
+	case historyActions[icon]:
+		g.applyHistoryAction(icon)
+		g.chipSelector.selectTool()
+	case selectionActions[icon]:
+		g.applySelectionAction(icon)
+		g.chipSelector.selectIcon(sprites.SelectIcon)
+	}
+	if icon, ok := historyShortcut(keyboard); ok {
+		g.applyHistoryAction(icon)
+	}
+	g.updateBoardKeys(keyboard)
+	if g.chipSelector.selectedIcon == sprites.SelectIcon {
+		g.updateSelection(pointer)
+		return
+	}
 	cur := pointer.CurrentPos()
 	switch pointer.Status() {
 	case engine.TouchDown:
 		if g.chipSelector.selectedType == model.NoChip {
 			if g.boardWindow.Contains(cur) && g.chipSelector.selectedIcon == sprites.TrashCanIcon {
 				g.board.Reset()
+				g.selection = model.Region{}
 				g.chipSelector.selectedIcon = sprites.NoIcon
 				g.chipSelector.selectedType = model.StartChip
 			}
@@ -214,6 +350,98 @@ func (g *View) updateBoard(pointer *engine.PointerTracker) {
 	}
 }
 
+// applyHistoryAction undoes or redoes the last edit of the board.
+func (g *View) applyHistoryAction(icon sprites.IconType) {
+	switch icon {
+	case sprites.UndoIcon:
+		g.history.Undo()
+	case sprites.RedoIcon:
+		g.history.Redo()
+	}
+	g.selection = g.board.Clip(g.selection)
+	g.selecting, g.moving = false, false
+}
+
+// historyShortcut returns the history action for the keys just pressed:
+// Ctrl+Z to undo, Ctrl+Y or Ctrl+Shift+Z to redo (Cmd instead of Ctrl also
+// works).
+func historyShortcut(k *engine.Keyboard) (sprites.IconType, bool) {
+	if !k.HasCommandModifier() {
+		return sprites.NoIcon, false
+	}
+	switch {
+	case k.JustPressed(ebiten.KeyZ) && k.IsPressed(ebiten.KeyShift):
+		return sprites.RedoIcon, true
+	case k.JustPressed(ebiten.KeyZ):
+		return sprites.UndoIcon, true
+	case k.JustPressed(ebiten.KeyY):
+		return sprites.RedoIcon, true
+	}
+	return sprites.NoIcon, false
+}
+
+// updateSelection handles the select tool: dragging outside the selection
+// selects a rectangle of slots, dragging the selection moves its chips.
+func (g *View) updateSelection(pointer *engine.PointerTracker) {
+	x, y, ok := g.slotCoords(pointer.CurrentPos())
+	p := model.Position{X: x, Y: y}
+	switch pointer.Status() {
+	case engine.TouchDown:
+		if !ok {
+			return
+		}
+		g.dragFrom, g.dragTo = p, p
+		g.moving = g.selection.Contains(p)
+		g.selecting = !g.moving
+		if g.selecting {
+			g.selection = model.RegionBetween(p, p)
+		}
+	case engine.Dragging:
+		if !ok {
+			return
+		}
+		g.dragTo = p
+		if g.selecting {
+			g.selection = model.RegionBetween(g.dragFrom, p)
+		}
+	case engine.TouchUp:
+		if g.moving && g.dragTo != g.dragFrom {
+			v := model.Velocity{Dx: g.dragTo.X - g.dragFrom.X, Dy: g.dragTo.Y - g.dragFrom.Y}
+			g.selection = g.board.Move(g.selection, v)
+		}
+		g.selecting, g.moving = false, false
+	}
+}
+
+// applySelectionAction applies an action of the board controls to the
+// selected slots.  Rotating or mirroring the selection keeps its top left
+// corner in place.  Chips that end up off the board are lost.
+func (g *View) applySelectionAction(icon sprites.IconType) {
+	if icon == sprites.PasteIcon {
+		if g.clipboard != nil {
+			g.selection = g.board.Paste(g.clipboard, g.selection.Min)
+		}
+		return
+	}
+	if g.selection.Empty() {
+		return
+	}
+	clip := g.board.Copy(g.selection)
+	switch icon {
+	case sprites.CopyIcon:
+		g.clipboard = clip
+	case sprites.CutIcon:
+		g.clipboard = clip
+		g.board.ClearRegion(g.selection)
+	case sprites.RotateIcon:
+		g.board.ClearRegion(g.selection)
+		g.selection = g.board.Paste(clip.Rotate(), g.selection.Min)
+	case sprites.MirrorIcon:
+		g.board.ClearRegion(g.selection)
+		g.selection = g.board.Paste(clip.FlipHorizontal(), g.selection.Min)
+	}
+}
+
 func (g *View) slotCoords(p image.Point) (int, int, bool) {
 	if !g.boardWindow.Contains(p) {
 		return 0, 0, false
@@ -226,14 +454,17 @@ func (g *View) slotCoords(p image.Point) (int, int, bool) {
 	return sx, sy, true
 }
 
-func (g *View) updateMaze(pointer *engine.PointerTracker) {
+func (g *View) updateMaze(pointer *engine.PointerTracker, keyboard *engine.Keyboard) {
 	g.gameControlSelector.Update(pointer.ForWindow(g.mazeControlsWindow))
+	g.gameControlSelector.UpdateKeys(keyboard)
 }
 
 func (g *View) Draw(screen *ebiten.Image) {
 	g.exitWindow.Canvas(screen).Draw(sprites.PlainIcons.ImageToDraw(sprites.BackIcon))
 	g.drawBoard(screen)
 	g.drawMaze(screen)
+	drawResetView(screen, g.boardWindow, &g.boardViewport)
+	drawResetView(screen, g.mazeWindow, &g.mazeViewport)
 	maxY := screen.Bounds().Max.Y
 	var msg string
 	var col color.Color
@@ -250,6 +481,77 @@ func (g *View) Draw(screen *ebiten.Image) {
 		col = color.White
 	}
 	engine.DrawText(screen, msg, 10, maxY-10, col)
+	if !g.playing {
+		y := maxY - 30
+		if g.showCursor {
+			engine.DrawText(screen, g.cursorStatus(), 10, y, color.White)
+			y -= 20
+		}
+		g.drawBoardWarnings(screen, y)
+	}
+	if g.levelErr != nil {
+		g.drawLevelError(screen)
+	}
+}
+
+// resetViewRect returns where the control to reset the zoom and pan of a
+// window is, in its top right corner.
+func resetViewRect(w *engine.Window) image.Rectangle {
+	r := w.OuterBounds()
+	return image.Rect(r.Max.X-32, r.Min.Y, r.Max.X, r.Min.Y+32)
+}
+
+// drawResetView draws the control to reset the zoom and pan of a window, if
+// it is zoomed or panned.
+func drawResetView(screen *ebiten.Image, w *engine.Window, vp *engine.Viewport) {
+	if vp.IsReset() {
+		return
+	}
+	img := sprites.PlainIcons.ImageToDraw(sprites.ResetViewIcon)
+	r := resetViewRect(w)
+	img.Options.GeoM.Translate(float64(r.Min.X+r.Max.X)/2, float64(r.Min.Y+r.Max.Y)/2)
+	screen.DrawImage(img.Image, img.Options)
+}
+
+func (g *View) drawLevelError(screen *ebiten.Image) {
+	col := color.RGBA{255, 0, 0, 255}
+	y := screen.Bounds().Min.Y
+	for _, line := range strings.Split(g.levelErr.Error(), "\n") {
+		y += 20
+		engine.DrawText(screen, line, 10, y, col)
+	}
+}
+
+// Above this number of warnings about the board, only the first ones are
+// shown so they do not cover the screen.
+const maxBoardWarnings = 4
+
+// updateBoardWarnings analyzes the board again if it has changed since the
+// last time.
+func (g *View) updateBoardWarnings() {
+	if g.warningsBoard != nil && g.warningsBoard.Equal(g.board) {
+		return
+	}
+	g.warnings = model.AnalyzeBoard(g.board)
+	g.warningsBoard = g.board.Clone()
+}
+
+// drawBoardWarnings shows the problems with the board, bottom line at y, so
+// they can be fixed before starting a run.
+func (g *View) drawBoardWarnings(screen *ebiten.Image, y int) {
+	var lines []string
+	for i, w := range g.warnings {
+		if i == maxBoardWarnings {
+			lines = append(lines, fmt.Sprintf("and %d more", len(g.warnings)-i))
+			break
+		}
+		lines = append(lines, w.String())
+	}
+	col := color.RGBA{255, 200, 0, 255}
+	for i := len(lines) - 1; i >= 0; i-- {
+		engine.DrawText(screen, lines[i], 10, y, col)
+		y -= 20
+	}
 }
 
 func (g *View) drawMaze(screen *ebiten.Image) {
@@ -261,11 +563,39 @@ func (g *View) drawMaze(screen *ebiten.Image) {
 	g.mazeRenderer.DrawMaze(g.mazeWindow.Canvas(screen), maze, float64(g.step)/60, g.count/60)
 }
 
+var (
+	selectionColor = color.NRGBA{80, 160, 255, 96}
+	moveColor      = color.NRGBA{255, 255, 255, 64}
+	cursorColor    = color.NRGBA{255, 200, 0, 255}
+)
+
 func (g *View) drawBoard(screen *ebiten.Image) {
 	if !g.playing {
 		g.chipSelector.Draw(g.boardControlsWindow.Canvas(screen), g.boardRenderer.chips)
 	}
-	g.boardRenderer.DrawCircuitBoard(g.boardWindow.Canvas(screen), g.board)
+	canvas := g.boardWindow.Canvas(screen)
+	g.boardRenderer.DrawCircuitBoard(canvas, g.board)
+	if !g.playing && g.showCursor {
+		g.boardRenderer.DrawCursor(canvas, g.cursor, cursorColor)
+	}
+	if !g.playing && g.chipSelector.selectedIcon == sprites.SelectIcon {
+		g.boardRenderer.DrawSelection(canvas, g.selection, selectionColor)
+		if g.moving {
+			v := model.Velocity{Dx: g.dragTo.X - g.dragFrom.X, Dy: g.dragTo.Y - g.dragFrom.Y}
+			g.boardRenderer.DrawSelection(canvas, g.board.Clip(g.selection.Translate(v)), moveColor)
+		}
+	}
+}
+
+func resizeBoard(b *model.CircuitBoard, width, height int) *model.CircuitBoard {
+	resized := model.NewCircuitBoard(width, height)
+	w, h := b.Size()
+	for y := 0; y < h && y < height; y++ {
+		for x := 0; x < w && x < width; x++ {
+			resized.SetChipAt(x, y, b.ChipAt(x, y))
+		}
+	}
+	return resized
 }
 
 func hSplit(r image.Rectangle, y int) (r1 image.Rectangle, r2 image.Rectangle) {
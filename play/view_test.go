@@ -0,0 +1,259 @@
+package play
+
+import (
+	"image"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arnodel/gobot2flags/engine"
+	"github.com/arnodel/gobot2flags/model"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestMain(m *testing.M) {
+	// The level controller logs every step
+	log.SetOutput(ioutil.Discard)
+	os.Exit(m.Run())
+}
+
+const flagAheadLevel = `
++--+--+--+
+|R> RF R |
++--+--+--+`
+
+// A viewTester drives a View with scripted input.
+type viewTester struct {
+	t    *testing.T
+	view *View
+	game *engine.Game
+}
+
+func newViewTester(t *testing.T) *viewTester {
+	level, err := model.LevelFromString("flag ahead", flagAheadLevel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	level.BoardWidth, level.BoardHeigth = 3, 2
+	vt := &viewTester{t: t, view: NewView(level, func() {})}
+	vt.game = engine.NewGame(vt.view)
+	vt.game.Layout(1024, 768)
+	// The board controls are laid out after the first frame
+	vt.run(engine.InputScript{}.Wait(2))
+	return vt
+}
+
+// run updates the view once for each frame of the script.
+func (vt *viewTester) run(script engine.InputScript) {
+	in := engine.NewScriptedInput(script)
+	vt.game.SetInput(in)
+	for !in.Done() {
+		if err := vt.game.Update(); err != nil {
+			vt.t.Fatal(err)
+		}
+	}
+}
+
+// slot returns the point of the screen showing a slot of the board.
+func (vt *viewTester) slot(x, y int) image.Point {
+	return vt.view.boardWindow.ScreenPos((float64(x)+0.5)*32, (float64(y)+0.5)*32)
+}
+
+// tile returns the point of the screen showing a tile of the board controls.
+func (vt *viewTester) tile(i int) image.Point {
+	return vt.view.boardControlsWindow.ScreenPos(vt.view.chipSelector.grid.CellCenter(i, 0))
+}
+
+// replay updates the view with the input recorded in a file of the testdata
+// directory.
+func (vt *viewTester) replay(name string) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		vt.t.Fatal(err)
+	}
+	recording, err := engine.InputRecordingFromJSON(data)
+	if err != nil {
+		vt.t.Fatal(err)
+	}
+	if w, h := vt.game.OutsideSize(); recording.Width != w || recording.Height != h {
+		vt.t.Fatalf("recorded at %dx%d, not %dx%d", recording.Width, recording.Height, w, h)
+	}
+	script, err := recording.Script()
+	if err != nil {
+		vt.t.Fatal(err)
+	}
+	vt.run(script)
+}
+
+func (vt *viewTester) checkBoard(want string) {
+	vt.t.Helper()
+	want = strings.TrimPrefix(want, "\n") + "\n"
+	if got := vt.view.board.String(); got != want {
+		vt.t.Errorf("got board:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestView_EditAndPlay(t *testing.T) {
+	vt := newViewTester(t)
+	var s engine.InputScript
+
+	// The start chip is selected to begin with
+	p := vt.slot(0, 0)
+	s = s.Click(p.X, p.Y)
+
+	// Select the forward chip with its key
+	s = s.Press(ebiten.KeyDigit2)
+	p = vt.slot(1, 0)
+	s = s.Click(p.X, p.Y)
+
+	// Select the yes arrow and draw it, then loop back to the forward chip
+	p = vt.tile(len(chipTypes))
+	s = s.Click(p.X, p.Y)
+	s = s.Drag(vt.slot(0, 0).X, vt.slot(0, 0).Y, vt.slot(1, 0).X, vt.slot(1, 0).Y, 4)
+	s = s.DragThrough(4, vt.slot(1, 0), vt.slot(2, 0), vt.slot(2, 1), vt.slot(1, 1), vt.slot(1, 0))
+
+	vt.run(s)
+	const loop = `
+|ST -> MF -> ..|
+|       ^     v|
+|..    .. <- ..|`
+	vt.checkBoard(loop)
+
+	// The loop is undone in one step
+	vt.run(engine.InputScript{}.Press(ebiten.KeyControlLeft, ebiten.KeyZ))
+	vt.checkBoard(`
+|ST -> MF    ..|
+|              |
+|..    ..    ..|`)
+	if len(vt.view.warnings) != 1 {
+		t.Errorf("got warnings %v, want one about the move forward chip", vt.view.warnings)
+	}
+
+	vt.run(engine.InputScript{}.Press(ebiten.KeyControlLeft, ebiten.KeyY))
+	vt.checkBoard(loop)
+	if len(vt.view.warnings) != 0 {
+		t.Errorf("got warnings %v, want none", vt.view.warnings)
+	}
+
+	vt.run(engine.InputScript{}.Press(ebiten.KeySpace).Wait(400))
+	if !vt.view.playing || !vt.view.boardController.GameWon() {
+		t.Error("expected the level to be won")
+	}
+
+	// Rewinding goes back to editing
+	vt.run(engine.InputScript{}.Press(ebiten.KeyR).Wait(1))
+	if vt.view.playing {
+		t.Error("expected the run to be rewound")
+	}
+}
+
+func TestView_PausedOnExit(t *testing.T) {
+	vt := newViewTester(t)
+	p := vt.slot(0, 0)
+	vt.run(engine.InputScript{}.Click(p.X, p.Y).Press(ebiten.KeySpace).Wait(10))
+	if !vt.view.playing {
+		t.Fatal("expected the run to start")
+	}
+	vt.game.PushView(NewView(vt.view.level, func() {}), engine.Transition{})
+	vt.run(engine.InputScript{}.Wait(1))
+	if got := vt.view.gameControlSelector.selectedControl; got != Pause {
+		t.Errorf("got control %d, want Pause", got)
+	}
+}
+
+// A pinch starts with one finger, which must not edit the board or switch
+// between the board and the maze.
+func TestView_Pinch(t *testing.T) {
+	vt := newViewTester(t)
+	const empty = `
+|..    ..    ..|
+|              |
+|..    ..    ..|`
+	maze := vt.view.mazeWindow.OuterBounds()
+	for _, p := range []image.Point{vt.slot(1, 0), maze.Min.Add(maze.Size().Div(2))} {
+		s := engine.InputScript{
+			{Touches: []engine.Touch{{ID: 1, X: p.X, Y: p.Y}}},
+			{Touches: []engine.Touch{{ID: 1, X: p.X, Y: p.Y}}},
+		}
+		for i := 1; i <= 10; i++ {
+			s = append(s, engine.InputFrame{Touches: []engine.Touch{
+				{ID: 1, X: p.X - i, Y: p.Y},
+				{ID: 2, X: p.X + 20 + i, Y: p.Y},
+			}})
+		}
+		vt.run(s.Wait(2))
+		vt.checkBoard(empty)
+		if !vt.view.showBoard {
+			t.Errorf("pinch at %v: expected the board to be shown", p)
+		}
+	}
+}
+
+func TestView_KeyboardEditing(t *testing.T) {
+	vt := newViewTester(t)
+	var s engine.InputScript
+
+	// The first arrow key shows the cursor at the top left slot
+	s = s.Press(ebiten.KeyArrowRight)
+	s = s.Press(ebiten.KeyEnter)
+	s = s.Press(ebiten.KeyDigit8) // Wall ahead
+	s = s.Press(ebiten.KeyArrowDown)
+	s = s.Press(ebiten.KeyEnter)
+	s = s.Press(ebiten.KeyDigit4) // Turn right
+	s = s.Press(ebiten.KeyArrowRight)
+	s = s.Press(ebiten.KeyEnter)
+
+	// Lay a no arrow from the decision chip to the turn right chip
+	s = s.Press(ebiten.KeyArrowLeft)
+	s = s.Press(ebiten.KeyN, ebiten.KeyArrowRight)
+
+	// Then a yes arrow from the start chip to the decision chip
+	s = s.Press(ebiten.KeyArrowUp)
+	s = s.Press(ebiten.KeyArrowLeft)
+	s = s.Press(ebiten.KeyY, ebiten.KeyArrowDown)
+	vt.run(s)
+	vt.checkBoard(`
+|ST    ..    ..|
+| v            |
+|W? n> TR    ..|`)
+	if got, want := vt.view.cursorStatus(), "Slot (0, 1): wall ahead? - no arrow east - Enter places turn right"; got != want {
+		t.Errorf("got status %q, want %q", got, want)
+	}
+
+	// Select the two chips at the bottom and move them right
+	s = engine.InputScript{}.Press(ebiten.KeyShift, ebiten.KeyArrowRight)
+	s = s.Press(ebiten.KeyM, ebiten.KeyArrowRight)
+	vt.run(s)
+	vt.checkBoard(`
+|ST    ..    ..|
+| v            |
+|..    W? n> TR|`)
+}
+
+// The recordings start with the board of flagAheadLevel empty and the start
+// chip selected.
+func TestView_Recordings(t *testing.T) {
+	tests := []struct {
+		recording string
+		board     string
+	}{
+		{
+			// Place two chips, draw a loop, undo and redo it
+			recording: "draw-loop.input.json",
+			board: `
+|ST -> MF -> ..|
+|       ^     v|
+|..    .. <- ..|`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.recording, func(t *testing.T) {
+			vt := newViewTester(t)
+			vt.replay(test.recording)
+			vt.checkBoard(test.board)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+// Package images gives access to the sprite sheets of the game as standard
+// library images, so that they can be used without ebiten, e.g. to draw mazes
+// on a headless server.
+package images
+
+import (
+	"embed"
+	"image"
+	_ "image/png" // This is so that png type is registered with the image package and image.Decode() works
+)
+
+// Size of the frames in the sprite sheets and of the walls in greywalls.png.
+const (
+	FrameWidth  = 32
+	FrameHeight = 32
+
+	WallWidth  = 6
+	WallHeight = 7
+)
+
+//go:embed *.png
+var images embed.FS
+
+// Get loads an image from the embedded filesystem.
+func Get(name string) image.Image {
+	f, err := images.Open(name)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		panic(err)
+	}
+	return img
+}
@@ -2,33 +2,46 @@ package resources
 
 import (
 	"embed"
-	"image"
-	_ "image/png" // This is so that png type is registered with the image package and image.Decode() works
+	"fmt"
+	"io/fs"
 	"io/ioutil"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/arnodel/gobot2flags/model"
+	"github.com/arnodel/gobot2flags/resources/images"
 	"github.com/hajimehoshi/ebiten/v2"
 	"golang.org/x/image/font/opentype"
 )
 
-//go:embed images fonts levels
+//go:embed fonts levels
 var resources embed.FS
 
-// GetImage loads an image from the embedded filesystem and converts it to an
-// ebiten image.
-func GetImage(name string) *ebiten.Image {
-	f, err := resources.Open("images/" + name)
-	if err != nil {
-		panic(err)
-	}
+// levels is the filesystem that levels are read from.  It defaults to the
+// embedded levels but can be switched to a directory on disk with
+// UseLevelDir.
+var levels fs.FS
 
-	img, _, err := image.Decode(f)
+func init() {
+	var err error
+	levels, err = fs.Sub(resources, "levels")
 	if err != nil {
 		panic(err)
 	}
-	eimg := ebiten.NewImageFromImage(img)
-	return eimg
+}
+
+// UseLevelDir makes GetLevelList and GetLevel read levels from dir instead of
+// the embedded filesystem.  This is useful when designing levels as they can
+// be edited without rebuilding the game.
+func UseLevelDir(dir string) {
+	levels = os.DirFS(dir)
+}
+
+// GetImage loads an image from the images package and converts it to an
+// ebiten image.
+func GetImage(name string) *ebiten.Image {
+	return ebiten.NewImageFromImage(images.Get(name))
 }
 
 func GetFont(name string) *opentype.Font {
@@ -47,28 +60,67 @@ func GetFont(name string) *opentype.Font {
 	return font
 }
 
+// GetLevelList returns the names of all the levels, in the order they should
+// be presented to the player.
 func GetLevelList() []string {
-	entries, err := resources.ReadDir("levels")
+	entries, err := fs.ReadDir(levels, ".")
 	if err != nil {
 		panic(err)
 	}
-	var levels []string
+	var names []string
+	seen := map[string]bool{}
 	for _, entry := range entries {
-		if entry.Type().IsRegular() && strings.HasSuffix(entry.Name(), ".r2f") {
-			levels = append(levels, strings.TrimSuffix(entry.Name(), ".r2f"))
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		format, ok := model.LevelFormatForFile(entry.Name())
+		if !ok {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), format.Ext)
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
 		}
 	}
-	return levels
+	return names
 }
 
+// GetLevel loads a level, using the decoder for the format of the level file.
 func GetLevel(name string) (*model.Level, error) {
-	f, err := resources.Open("levels/" + name + ".r2f")
+	filename, format, err := levelFile(name)
 	if err != nil {
 		return nil, err
 	}
-	data, err := ioutil.ReadAll(f)
+	data, err := fs.ReadFile(levels, filename)
 	if err != nil {
 		return nil, err
 	}
-	return model.LevelFromString(name, string(data))
+	return format.Decode(name, data)
+}
+
+// GetLevelModTime returns the last modification time of a level file.  Levels
+// in the embedded filesystem always have a zero modification time.
+func GetLevelModTime(name string) (time.Time, error) {
+	filename, _, err := levelFile(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	info, err := fs.Stat(levels, filename)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// levelFile finds the file containing a level, trying each supported level
+// format in turn.
+func levelFile(name string) (string, model.LevelFormat, error) {
+	for _, format := range model.LevelFormats {
+		filename := name + format.Ext
+		if _, err := fs.Stat(levels, filename); err == nil {
+			return filename, format, nil
+		}
+	}
+	return "", model.LevelFormat{}, fmt.Errorf("level %s not found", name)
 }
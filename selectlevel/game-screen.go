@@ -12,8 +12,9 @@ type View struct {
 	levels        []string
 	grid          engine.Grid
 	selector      engine.Selector
-	selectedLevel int
+	selectedLevel int // Level highlighted with the arrow keys, or -1
 	selectLevel   func(int)
+	exit          func() // Called when Escape is pressed, if not nil
 }
 
 var _ engine.View = (*View)(nil)
@@ -26,6 +27,11 @@ func NewView(levels []string, selectLevel func(int)) *View {
 	}
 }
 
+// SetExitHandler sets a function to call when Escape is pressed.
+func (v *View) SetExitHandler(exit func()) {
+	v.exit = exit
+}
+
 func (v *View) Update(vc engine.ViewContainer) error {
 	w, _ := vc.OutsideSize()
 	v.grid = engine.Grid{
@@ -36,16 +42,48 @@ func (v *View) Update(vc engine.ViewContainer) error {
 	}
 	pointer := vc.Pointer()
 	if v.selector.Update(v.selectingLevel(pointer.CurrentPos()), pointer.Status()) == engine.Select {
+		v.selectedLevel = v.selector.SelectIndex
 		v.selectLevel(v.selector.SelectIndex)
 	}
+	v.updateKeys(vc.Keyboard())
 	return nil
 }
 
+// updateKeys moves the highlighted level with the arrow keys and selects it
+// with Enter or space.
+func (v *View) updateKeys(k *engine.Keyboard) {
+	if k.HasCommandModifier() {
+		return
+	}
+	if k.JustPressed(ebiten.KeyEscape) && v.exit != nil {
+		v.exit()
+		return
+	}
+	if len(v.levels) == 0 {
+		return
+	}
+	switch {
+	case k.Repeated(ebiten.KeyArrowDown):
+		v.selectedLevel = (v.selectedLevel + 1) % len(v.levels)
+	case k.Repeated(ebiten.KeyArrowUp):
+		if v.selectedLevel <= 0 {
+			v.selectedLevel = len(v.levels)
+		}
+		v.selectedLevel--
+	case k.JustPressed(ebiten.KeyEnter), k.JustPressed(ebiten.KeySpace):
+		if v.selectedLevel >= 0 {
+			v.selectLevel(v.selectedLevel)
+		}
+	}
+}
+
 func (v *View) Draw(screen *ebiten.Image) {
 	for i, level := range v.levels {
 		var col color.Color
 		if v.selector.IsSelecting(i) {
 			col = color.RGBA{0xff, 0, 0, 0xff}
+		} else if i == v.selectedLevel {
+			col = color.RGBA{0xff, 0xc8, 0, 0xff}
 		} else {
 			col = color.White
 		}
@@ -0,0 +1,55 @@
+package selectlevel
+
+import (
+	"testing"
+
+	"github.com/arnodel/gobot2flags/engine"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestView_Select(t *testing.T) {
+	tests := []struct {
+		name   string
+		script engine.InputScript
+		want   int
+	}{
+		{
+			name:   "Click",
+			script: engine.InputScript{}.Click(100, 75),
+			want:   2,
+		},
+		{
+			name:   "Arrow keys",
+			script: engine.InputScript{}.Press(ebiten.KeyArrowDown).Press(ebiten.KeyArrowDown).Press(ebiten.KeyEnter),
+			want:   1,
+		},
+		{
+			name:   "Wrap around",
+			script: engine.InputScript{}.Press(ebiten.KeyArrowUp).Press(ebiten.KeySpace),
+			want:   2,
+		},
+		{
+			name:   "Nothing highlighted",
+			script: engine.InputScript{}.Press(ebiten.KeyEnter),
+			want:   -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected := -1
+			v := NewView([]string{"one", "two", "three"}, func(i int) { selected = i })
+			g := engine.NewGame(v)
+			g.Layout(640, 480)
+			in := engine.NewScriptedInput(tt.script)
+			g.SetInput(in)
+			for !in.Done() {
+				if err := g.Update(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if selected != tt.want {
+				t.Errorf("got level %d, want %d", selected, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package solver
+
+import (
+	"math/rand"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+// Number of random placements tried before giving up on laying out a
+// program.
+const layoutAttempts = 100
+
+// A LayoutNode is a chip to place with Layout.
+type LayoutNode struct {
+	Chip model.ChipType
+
+	// Nodes that the yes and no arrows of the chip point to, -1 for no
+	// arrow.  Only decision chips use the no arrow.
+	Exits [2]int
+}
+
+// Layout places chips on a circuit board of the given size like the solver
+// does for its solutions, with the start chip at the given position.  The
+// first node must be the start chip.  Nodes of type NoChip are left as empty
+// slots with no arrows, which stop the run when reached.  It returns false if
+// the chips do not fit.
+func Layout(nodes []LayoutNode, width, height int, start model.Position) (*model.CircuitBoard, bool) {
+	prog := make(program, len(nodes))
+	for i, n := range nodes {
+		prog[i] = node{chip: n.Chip, exits: n.Exits}
+	}
+	return layout(prog, width, height, start)
+}
+
+// layout places a program on a circuit board, with the start chip at the
+// given position.  Exits of chips are connected to chips next to them when
+// possible, otherwise through a path of empty slots.
+func layout(prog program, width, height int, start model.Position) (*model.CircuitBoard, bool) {
+	for i := 0; i < layoutAttempts; i++ {
+		l := newLayouter(prog, width, height, rand.New(rand.NewSource(int64(i))))
+		if board, ok := l.layout(start); ok {
+			return board, true
+		}
+	}
+	return nil, false
+}
+
+type layouter struct {
+	prog          program
+	width, height int
+	rnd           *rand.Rand
+
+	pos    []model.Position // Position of each node, if placed
+	placed []bool
+	preds  [][]int // Nodes with an exit pointing to each node
+
+	// For each slot, the node it contains (or -1) and the direction of its
+	// arrows
+	slotNode   []int
+	slotArrows [][2]int // Direction of the yes and no arrows, or -1
+	wireTarget []int    // For wires, the node they lead to (or -1)
+}
+
+func newLayouter(prog program, width, height int, rnd *rand.Rand) *layouter {
+	l := &layouter{
+		prog:       prog,
+		width:      width,
+		height:     height,
+		rnd:        rnd,
+		pos:        make([]model.Position, len(prog)),
+		placed:     make([]bool, len(prog)),
+		preds:      make([][]int, len(prog)),
+		slotNode:   make([]int, width*height),
+		slotArrows: make([][2]int, width*height),
+		wireTarget: make([]int, width*height),
+	}
+	for i := range l.slotNode {
+		l.slotNode[i] = -1
+		l.slotArrows[i] = [2]int{-1, -1}
+		l.wireTarget[i] = -1
+	}
+	for n, node := range prog {
+		for _, m := range node.exits {
+			if m >= 0 {
+				l.preds[m] = append(l.preds[m], n)
+			}
+		}
+	}
+	return l
+}
+
+type edge struct {
+	from, exit, to int
+}
+
+func (l *layouter) layout(start model.Position) (*model.CircuitBoard, bool) {
+	if !l.contains(start) {
+		return nil, false
+	}
+	l.place(0, start)
+
+	// Place nodes in breadth first order, next to the node whose exit points
+	// to them if possible.
+	var toRoute []edge
+	queue := []int{0}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for exit, m := range l.prog[n].exits {
+			if m < 0 {
+				continue
+			}
+			e := edge{from: n, exit: exit, to: m}
+			if l.placed[m] {
+				toRoute = append(toRoute, e)
+				continue
+			}
+			if dir, p, ok := l.freeNeighbour(n, m); ok {
+				l.place(m, p)
+				l.setArrow(l.pos[n], exit, dir)
+			} else if p, ok := l.nearestFree(l.pos[n]); ok {
+				l.place(m, p)
+				toRoute = append(toRoute, e)
+			} else {
+				return nil, false
+			}
+			queue = append(queue, m)
+		}
+	}
+
+	// Then connect the remaining exits through wires.
+	for _, e := range toRoute {
+		if !l.route(e) {
+			return nil, false
+		}
+	}
+	return l.board(), true
+}
+
+func (l *layouter) place(n int, p model.Position) {
+	l.pos[n] = p
+	l.placed[n] = true
+	l.slotNode[l.index(p)] = n
+}
+
+func (l *layouter) setArrow(p model.Position, exit int, dir model.Orientation) {
+	l.slotArrows[l.index(p)][exit] = int(dir)
+}
+
+// freeNeighbour returns a random free slot next to node n that it can point
+// to, to place node m.
+func (l *layouter) freeNeighbour(n, m int) (model.Orientation, model.Position, bool) {
+	p := l.pos[n]
+	var (
+		dirs  []model.Orientation
+		score []int
+		total int
+	)
+	for o := model.North; o <= model.West; o++ {
+		q := p.Move(o.VelocityForward())
+		if !l.canPoint(p, o) || !l.isFree(q) {
+			continue
+		}
+		// Prefer slots with more room around them, and even more slots
+		// that other nodes pointing to m can point to directly.
+		s := 1
+		for o2 := model.North; o2 <= model.West; o2++ {
+			r := q.Move(o2.VelocityForward())
+			if l.isFree(r) {
+				s += 2
+			} else if k := l.nodeAt(r); k >= 0 && k != n && containsInt(l.preds[m], k) && l.canPoint(r, o2.Reverse()) {
+				s += 20
+			}
+		}
+		dirs = append(dirs, o)
+		score = append(score, s)
+		total += s
+	}
+	if total == 0 {
+		return 0, model.Position{}, false
+	}
+	r := l.rnd.Intn(total)
+	for i, s := range score {
+		if r < s {
+			return dirs[i], p.Move(dirs[i].VelocityForward()), true
+		}
+		r -= s
+	}
+	panic("unreachable")
+}
+
+// nearestFree returns the closest free slot to p.
+func (l *layouter) nearestFree(p model.Position) (model.Position, bool) {
+	best, bestDist := model.Position{}, -1
+	for y := 0; y < l.height; y++ {
+		for x := 0; x < l.width; x++ {
+			q := model.Position{X: x, Y: y}
+			if !l.isFree(q) {
+				continue
+			}
+			d := abs(q.X-p.X) + abs(q.Y-p.Y)
+			if bestDist < 0 || d < bestDist {
+				best, bestDist = q, d
+			}
+		}
+	}
+	return best, bestDist >= 0
+}
+
+// route connects an exit of a node to its target through free slots, which
+// become wires.  The path can join an existing wire that leads to the same
+// target.
+func (l *layouter) route(e edge) bool {
+	from := l.pos[e.from]
+	if e.from != e.to {
+		return l.routeFrom(e, from)
+	}
+	// A chip that points to itself needs a loop of wires.  Try each first
+	// wire in turn, the path cannot come straight back from it as two slots
+	// cannot point to each other.
+	for _, o := range l.rnd.Perm(4) {
+		dir := model.Orientation(o)
+		if first := from.Move(dir.VelocityForward()); l.isFree(first) && l.canPoint(from, dir) && l.routeFrom(e, first) {
+			return true
+		}
+	}
+	return false
+}
+
+// routeFrom is route with the path starting from the slot start, which is
+// either the chip at the start of the edge or a free slot next to it.
+func (l *layouter) routeFrom(e edge, start model.Position) bool {
+	from := l.pos[e.from]
+	parent := map[model.Position]model.Position{}
+	queue := []model.Position{start}
+	if start != from {
+		parent[start] = from
+	}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for _, o := range l.rnd.Perm(4) {
+			dir := model.Orientation(o)
+			q := p.Move(dir.VelocityForward())
+			if !l.contains(q) || !l.canPoint(p, dir) || q == from && p == start {
+				continue
+			}
+			if l.slotNode[l.index(q)] == e.to || l.wireTarget[l.index(q)] == e.to {
+				// Found the target, lay the wires back to the start
+				for p != from {
+					l.slotArrows[l.index(p)][yesExit] = int(dir)
+					l.wireTarget[l.index(p)] = e.to
+					pp := parent[p]
+					dir, _ = model.Velocity{Dx: p.X - pp.X, Dy: p.Y - pp.Y}.Orientation()
+					p = pp
+				}
+				l.setArrow(from, e.exit, dir)
+				return true
+			}
+			if _, seen := parent[q]; seen || q == from || !l.isFree(q) {
+				continue
+			}
+			parent[q] = p
+			queue = append(queue, q)
+		}
+	}
+	return false
+}
+
+// canPoint returns true if an arrow can go from p in the given direction,
+// i.e. the slot in that direction does not already point back to p.  It does
+// not check that p itself does not already have an arrow in that direction.
+func (l *layouter) canPoint(p model.Position, dir model.Orientation) bool {
+	q := p.Move(dir.VelocityForward())
+	if !l.contains(q) {
+		return false
+	}
+	back := int(dir.Reverse())
+	arrows := l.slotArrows[l.index(q)]
+	if arrows[yesExit] == back || arrows[noExit] == back {
+		return false
+	}
+	arrows = l.slotArrows[l.index(p)]
+	return arrows[yesExit] != int(dir) && arrows[noExit] != int(dir)
+}
+
+// nodeAt returns the node at p, or -1.
+func (l *layouter) nodeAt(p model.Position) int {
+	if !l.contains(p) {
+		return -1
+	}
+	return l.slotNode[l.index(p)]
+}
+
+func (l *layouter) isFree(p model.Position) bool {
+	if !l.contains(p) {
+		return false
+	}
+	i := l.index(p)
+	return l.slotNode[i] < 0 && l.wireTarget[i] < 0
+}
+
+func (l *layouter) board() *model.CircuitBoard {
+	b := model.NewCircuitBoard(l.width, l.height)
+	for y := 0; y < l.height; y++ {
+		for x := 0; x < l.width; x++ {
+			i := l.index(model.Position{X: x, Y: y})
+			var chip model.Chip
+			if n := l.slotNode[i]; n >= 0 {
+				chip = chip.WithType(l.prog[n].chip)
+			}
+			if dir := l.slotArrows[i][yesExit]; dir >= 0 {
+				chip = chip.WithArrowYes(model.Orientation(dir))
+			}
+			if dir := l.slotArrows[i][noExit]; dir >= 0 {
+				chip = chip.WithArrowNo(model.Orientation(dir))
+			}
+			b.SetChipAt(x, y, chip)
+		}
+	}
+	return b
+}
+
+func (l *layouter) contains(p model.Position) bool {
+	return p.X >= 0 && p.X < l.width && p.Y >= 0 && p.Y < l.height
+}
+
+func (l *layouter) index(p model.Position) int {
+	return p.X + p.Y*l.width
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
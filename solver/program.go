@@ -0,0 +1,33 @@
+package solver
+
+import "github.com/arnodel/gobot2flags/model"
+
+// A program is a circuit board viewed as a graph.  The first node is the
+// start chip.
+type program []node
+
+type node struct {
+	chip model.ChipType
+
+	// Nodes that the yes and no exits point to, -1 if the exit does not
+	// point anywhere.  Only decision chips use the no exit.
+	exits [2]int
+}
+
+const (
+	yesExit = 0
+	noExit  = 1
+)
+
+func exitIndex(yes bool) int {
+	if yes {
+		return yesExit
+	}
+	return noExit
+}
+
+// chipCount returns the number of chips in the program, not counting the
+// start chip, like model.CircuitBoard.ChipCount.
+func (p program) chipCount() int {
+	return len(p) - 1
+}
@@ -0,0 +1,352 @@
+// Package solver searches for the cheapest circuit boards that win a level.
+//
+// The search works on programs, i.e. graphs whose nodes are chips and where
+// each exit of a chip points to another chip.  Programs are built lazily:
+// the program being built is run on the level and only when the run reaches
+// an exit that does not point anywhere does the search try all the ways to
+// continue, pointing the exit to an existing chip or to a new one.  Programs
+// are explored depth first, by iterative deepening over the number of chips.
+//
+// When a winning program is found, it is laid out on the circuit board with
+// empty slots used as wires to connect chips that are not next to each other.
+package solver
+
+import (
+	"errors"
+	"hash/fnv"
+	"time"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+// Options control the extent of the search.  Zero values mean the default
+// is used.
+type Options struct {
+	MaxChips int           // Largest number of chips to try (default 6)
+	MaxSteps int           // Runs longer than this fail (default 1000)
+	MaxNodes int           // Give up after exploring that many partial boards (default unlimited)
+	Timeout  time.Duration // Give up after that long (default unlimited)
+
+	// Start is the position of the start chip on the board (default the
+	// center of the board).
+	Start *model.Position
+
+	// ChipTypes are the chips that can be used (default all of them).
+	ChipTypes []model.ChipType
+}
+
+// A Solution is a circuit board that wins the level.
+type Solution struct {
+	Board *model.CircuitBoard
+	Chips int // Number of chips, not counting the start chip
+	Steps int // Number of commands executed
+	Cost  int // Cost of the run, as computed by model.LevelController
+}
+
+// Result is the outcome of a search.
+type Result struct {
+	Best  *Solution // The cheapest solution found, nil if none was found
+	Nodes int       // Number of partial boards explored
+
+	// Complete is true if the search was not interrupted by MaxNodes or
+	// Timeout.  In that case there is no cheaper solution within the limits
+	// given by the other options.
+	Complete bool
+}
+
+var allChipTypes = []model.ChipType{
+	model.ForwardChip,
+	model.TurnLeftChip,
+	model.TurnRightChip,
+	model.IsWallAheadChip,
+	model.IsFloorRedChip,
+	model.IsFloorYellowChip,
+	model.IsFloorBlueChip,
+	model.PaintRedChip,
+	model.PaintYellowChip,
+	model.PaintBlueChip,
+}
+
+// Solve searches for the cheapest circuit board that wins the level.
+func Solve(level *model.Level, opts Options) (Result, error) {
+	if level.Maze == nil || level.Maze.Robot() == nil {
+		return Result{}, errors.New("the level has no robot")
+	}
+	if level.BoardWidth < 1 || level.BoardHeigth < 1 {
+		return Result{}, errors.New("the level has an empty circuit board")
+	}
+	s := newSearcher(level, opts)
+	start := s.startState()
+	for s.chipLimit = 0; s.chipLimit <= s.opts.MaxChips; s.chipLimit++ {
+		if s.best != nil && s.chipLimit*level.ChipCost+s.minMoveCost(start) >= s.best.Cost {
+			break
+		}
+		s.hitChipLimit = false
+		s.search(start.clone())
+		if s.aborted || !s.hitChipLimit {
+			break
+		}
+	}
+	return Result{
+		Best:     s.best,
+		Nodes:    s.nodes,
+		Complete: !s.aborted,
+	}, nil
+}
+
+type searcher struct {
+	level     *model.Level
+	opts      Options
+	deadline  time.Time
+	chipLimit int
+
+	best         *Solution
+	nodes        int
+	aborted      bool
+	hitChipLimit bool
+}
+
+func newSearcher(level *model.Level, opts Options) *searcher {
+	if opts.MaxChips == 0 {
+		opts.MaxChips = 6
+	}
+	if opts.MaxSteps == 0 {
+		opts.MaxSteps = 1000
+	}
+	if opts.Start == nil {
+		opts.Start = &model.Position{X: level.BoardWidth / 2, Y: level.BoardHeigth / 2}
+	}
+	if opts.ChipTypes == nil {
+		opts.ChipTypes = allChipTypes
+	}
+	s := &searcher{level: level, opts: opts}
+	if opts.Timeout > 0 {
+		s.deadline = time.Now().Add(opts.Timeout)
+	}
+	return s
+}
+
+// state is a partial program together with the state of a run of that
+// program, stopped at the point where the program needs to be extended.
+type state struct {
+	prog  program
+	maze  *model.Maze
+	node  int   // The current node of the program
+	walk  []int // Nodes visited since the last command
+	steps int
+	cost  int
+}
+
+func (s *searcher) startState() *state {
+	return &state{
+		prog: program{{chip: model.StartChip, exits: [2]int{-1, -1}}},
+		maze: s.level.Maze.Clone(),
+	}
+}
+
+func (st *state) clone() *state {
+	clone := *st
+	clone.prog = append(program(nil), st.prog...)
+	clone.maze = st.maze.Clone()
+	clone.walk = append([]int(nil), st.walk...)
+	return &clone
+}
+
+func (s *searcher) search(st *state) {
+	if s.outOfBudget() {
+		return
+	}
+	s.nodes++
+	exit, ok := s.run(st)
+	if !ok {
+		return
+	}
+	for _, child := range s.extensions(st, exit) {
+		s.search(child)
+		if s.aborted {
+			return
+		}
+	}
+}
+
+func (s *searcher) outOfBudget() bool {
+	if s.aborted {
+		return true
+	}
+	if s.opts.MaxNodes > 0 && s.nodes >= s.opts.MaxNodes {
+		s.aborted = true
+	} else if !s.deadline.IsZero() && s.nodes%256 == 0 && time.Now().After(s.deadline) {
+		s.aborted = true
+	}
+	return s.aborted
+}
+
+// stateKey identifies the state of a run on a fixed board.  If a run gets to
+// the same key twice, it is in an infinite loop.
+type stateKey struct {
+	robot    model.Position
+	facing   model.Orientation
+	node     int
+	captured int
+	colors   uint64
+}
+
+// run executes the program until the level is won, the run fails, or the
+// current node has an exit that does not point anywhere.  In the last case
+// it returns that exit and true.
+//
+// This follows the same logic as model.LevelController.NextCommand.
+func (s *searcher) run(st *state) (int, bool) {
+	seen := map[stateKey]bool{}
+	if st.maze.FlagsRemaining() == 0 {
+		s.record(st)
+		return 0, false
+	}
+	for {
+		var (
+			robot      = st.maze.Robot()
+			wallAhead  = st.maze.HasWallAt(robot.X, robot.Y, robot.Orientation)
+			floorColor = st.maze.CellAt(robot.X, robot.Y).Color()
+			chip       = model.Chip(0).WithType(st.prog[st.node].chip)
+			com, yes   = chip.Command(floorColor, wallAhead)
+			exit       = exitIndex(yes)
+			next       = st.prog[st.node].exits[exit]
+		)
+		if next < 0 {
+			return exit, true
+		}
+		st.walk = append(st.walk, st.node)
+		st.node = next
+		if com == model.NoCommand {
+			if containsInt(st.walk, next) {
+				return 0, false
+			}
+			continue
+		}
+		st.walk = st.walk[:0]
+		st.steps++
+		st.cost += s.level.MoveCost
+		st.maze.CommandRobot(com)
+		st.maze.AdvanceRobot()
+		if st.maze.FlagsRemaining() == 0 {
+			s.record(st)
+			return 0, false
+		}
+		if st.steps >= s.opts.MaxSteps || !s.canImprove(st, 0) {
+			return 0, false
+		}
+		key := s.stateKey(st)
+		if seen[key] {
+			return 0, false
+		}
+		seen[key] = true
+	}
+}
+
+// extensions returns the states obtained by pointing an exit of the current
+// node to an existing node or to a new one.
+func (s *searcher) extensions(st *state, exit int) []*state {
+	var (
+		children []*state
+		n        = st.node
+		other    = -1
+	)
+	if st.prog[n].chip.IsDecision() {
+		other = st.prog[n].exits[1-exit]
+	}
+	for m := range st.prog {
+		if m == other {
+			// Both exits would go to the same place so the test is useless
+			continue
+		}
+		child := st.clone()
+		child.prog[n].exits[exit] = m
+		children = append(children, child)
+	}
+	if st.prog.chipCount() >= s.chipLimit {
+		s.hitChipLimit = true
+	} else if s.canImprove(st, s.level.ChipCost) {
+		for _, t := range s.opts.ChipTypes {
+			child := st.clone()
+			child.prog[n].exits[exit] = len(child.prog)
+			child.prog = append(child.prog, node{chip: t, exits: [2]int{-1, -1}})
+			child.cost += s.level.ChipCost
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// canImprove returns false if a state, with extra cost added, cannot lead to
+// a solution cheaper than the best one so far.
+func (s *searcher) canImprove(st *state, extraCost int) bool {
+	if s.best == nil {
+		return true
+	}
+	return st.cost+extraCost+s.minMoveCost(st) < s.best.Cost
+}
+
+// minMoveCost is a lower bound for the cost of the moves still needed to win:
+// each flag left needs at least one move to be captured.
+func (s *searcher) minMoveCost(st *state) int {
+	return st.maze.FlagsRemaining() * s.level.MoveCost
+}
+
+func (s *searcher) record(st *state) {
+	if s.best != nil && s.best.Cost <= st.cost {
+		return
+	}
+	board, ok := layout(st.prog, s.level.BoardWidth, s.level.BoardHeigth, *s.opts.Start)
+	if !ok {
+		// The program does not fit on the board
+		return
+	}
+	// Check the board wins the level the same way when played for real
+	result := model.CompileBoard(board).Run(s.level, s.opts.MaxSteps)
+	if result.Outcome != model.Won || result.Cost != st.cost {
+		return
+	}
+	s.best = &Solution{
+		Board: board,
+		Chips: result.Chips,
+		Steps: result.Steps,
+		Cost:  result.Cost,
+	}
+}
+
+func (s *searcher) stateKey(st *state) stateKey {
+	w, h := st.maze.Size()
+	robot := st.maze.Robot()
+	colors := fnv.New64a()
+	var buf [1]byte
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			buf[0] = byte(st.maze.CellAt(x, y).Color())
+			colors.Write(buf[:])
+		}
+	}
+	return stateKey{
+		robot:    model.Position{X: mod(robot.X, w), Y: mod(robot.Y, h)},
+		facing:   robot.Orientation,
+		node:     st.node,
+		captured: st.maze.FlagsCaptured(),
+		colors:   colors.Sum64(),
+	}
+}
+
+func containsInt(ns []int, n int) bool {
+	for _, m := range ns {
+		if m == n {
+			return true
+		}
+	}
+	return false
+}
+
+func mod(a, b int) int {
+	a %= b
+	if a < 0 {
+		a += b
+	}
+	return a
+}
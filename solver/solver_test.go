@@ -0,0 +1,89 @@
+package solver
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/arnodel/gobot2flags/model"
+)
+
+func TestMain(m *testing.M) {
+	// model.RunLevel logs every step
+	log.SetOutput(ioutil.Discard)
+	os.Exit(m.Run())
+}
+
+func TestSolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    string
+		wantCost int
+	}{
+		{
+			name: "Straight",
+			level: `
++--+--+--+--+--+--+--+--+
+|R> R  R  RF R  R  R  RF|
++--+--+--+--+--+--+--+--+`,
+			wantCost: 17,
+		},
+		{
+			name: "Turn",
+			level: `
++--+--+--+
+|Rv|R  R |
++  +--+--+
+|R  R  RF|
++--+--+--+`,
+			wantCost: 34,
+		},
+		{
+			name: "Paint",
+			level: `
++--+--+--+
+|B> BF B |
++--+--+--+`,
+			wantCost: 11,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, err := model.LevelFromString(tt.name, tt.level)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := Solve(level, Options{MaxChips: 4, MaxNodes: 100000})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !res.Complete {
+				t.Errorf("search not complete after %d nodes", res.Nodes)
+			}
+			if res.Best == nil {
+				t.Fatal("no solution found")
+			}
+			if res.Best.Cost != tt.wantCost {
+				t.Errorf("cost = %d, want %d\n%s", res.Best.Cost, tt.wantCost, res.Best.Board)
+			}
+			run := model.RunLevel(level, res.Best.Board, 1000)
+			if run.Outcome != model.Won || run.Cost != res.Best.Cost {
+				t.Errorf("RunLevel() = %s with cost %d\n%s", run.Outcome, run.Cost, res.Best.Board)
+			}
+		})
+	}
+}
+
+func TestSolve_NoRobot(t *testing.T) {
+	level, err := model.LevelFromString("norobot", `
++--+--+
+|RF R |
++--+--+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Solve(level, Options{}); err == nil {
+		t.Error("expected an error")
+	}
+}
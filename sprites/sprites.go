@@ -6,15 +6,16 @@ import (
 	"github.com/arnodel/gobot2flags/engine"
 	"github.com/arnodel/gobot2flags/model"
 	"github.com/arnodel/gobot2flags/resources"
+	"github.com/arnodel/gobot2flags/resources/images"
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
 const (
-	FrameWidth  = 32
-	FrameHeight = 32
+	FrameWidth  = images.FrameWidth
+	FrameHeight = images.FrameHeight
 
-	WallWidth  = 6
-	WallHeight = 7
+	WallWidth  = images.WallWidth
+	WallHeight = images.WallHeight
 )
 
 type IconType int
@@ -28,6 +29,15 @@ const (
 	TrashCanIcon
 	EraserIcon
 	BackIcon
+	SelectIcon
+	CopyIcon
+	CutIcon
+	PasteIcon
+	RotateIcon
+	MirrorIcon
+	UndoIcon
+	RedoIcon
+	ResetViewIcon
 )
 
 const NoIcon IconType = -1